@@ -0,0 +1,190 @@
+// Package addrtrace records memory access traces in a compact binary format.
+//
+// A trace starts with an 8 byte header ("CCTRACE" and a version byte)
+// followed by records. Each record is a kind byte, the access size as an
+// unsigned varint and the address as a signed varint delta from the
+// previous record's address.
+package addrtrace
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/maphash"
+	"io"
+	"unsafe"
+)
+
+const (
+	magic   = "CCTRACE"
+	version = 1
+)
+
+// ErrBadHeader is returned by NewReader when the input is not a trace.
+var ErrBadHeader = errors.New("not an address trace")
+
+// Kind is what an access reads.
+type Kind uint8
+
+const (
+	Active        Kind = iota // User.Active
+	CountryHeader             // User.Country string header
+	CountryBytes              // bytes of User.Country
+	MapBucket                 // approximate map bucket of a country
+	Other
+)
+
+var kindNames = [...]string{"Active", "CountryHeader", "CountryBytes", "MapBucket", "Other"}
+
+func (k Kind) String() string {
+	if int(k) < len(kindNames) {
+		return kindNames[k]
+	}
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+// Access is a single memory read.
+type Access struct {
+	Addr uint64
+	Size uint32
+	Kind Kind
+}
+
+// Writer writes a trace.
+type Writer struct {
+	w    *bufio.Writer
+	prev uint64
+	buf  [1 + 2*binary.MaxVarintLen64]byte
+	n    int
+}
+
+// NewWriter writes a trace header to w and returns a Writer.
+// Call Flush when done.
+func NewWriter(w io.Writer) (*Writer, error) {
+	tw := Writer{w: bufio.NewWriter(w)}
+	if _, err := tw.w.WriteString(magic); err != nil {
+		return nil, err
+	}
+	if err := tw.w.WriteByte(version); err != nil {
+		return nil, err
+	}
+
+	return &tw, nil
+}
+
+// Write writes a single access.
+// Like bufio.Writer, after the first error all writes and Flush return it.
+func (w *Writer) Write(a Access) error {
+	w.buf[0] = byte(a.Kind)
+	n := 1
+	n += binary.PutUvarint(w.buf[n:], uint64(a.Size))
+	n += binary.PutVarint(w.buf[n:], int64(a.Addr-w.prev))
+	w.prev = a.Addr
+	w.n++
+
+	_, err := w.w.Write(w.buf[:n])
+	return err
+}
+
+// Read records a read of size bytes at ptr.
+func (w *Writer) Read(ptr unsafe.Pointer, size uintptr, kind Kind) error {
+	return w.Write(Access{Addr: uint64(uintptr(ptr)), Size: uint32(size), Kind: kind})
+}
+
+// Count returns the number of accesses written.
+func (w *Writer) Count() int {
+	return w.n
+}
+
+// Flush writes buffered data to the underlying writer.
+func (w *Writer) Flush() error {
+	return w.w.Flush()
+}
+
+// Reader reads a trace.
+type Reader struct {
+	r    *bufio.Reader
+	prev uint64
+}
+
+// NewReader reads the trace header from r and returns a Reader.
+func NewReader(r io.Reader) (*Reader, error) {
+	tr := Reader{r: bufio.NewReader(r)}
+	var hdr [len(magic) + 1]byte
+	if _, err := io.ReadFull(tr.r, hdr[:]); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrBadHeader, err)
+	}
+	if string(hdr[:len(magic)]) != magic {
+		return nil, ErrBadHeader
+	}
+	if v := hdr[len(magic)]; v != version {
+		return nil, fmt.Errorf("%w: unknown version %d", ErrBadHeader, v)
+	}
+
+	return &tr, nil
+}
+
+// Read returns the next access, or io.EOF at the end of the trace.
+func (r *Reader) Read() (Access, error) {
+	kind, err := r.r.ReadByte()
+	if err != nil {
+		return Access{}, err // io.EOF on a record boundary
+	}
+
+	size, err := binary.ReadUvarint(r.r)
+	if err != nil {
+		return Access{}, unexpected(err)
+	}
+
+	delta, err := binary.ReadVarint(r.r)
+	if err != nil {
+		return Access{}, unexpected(err)
+	}
+
+	r.prev += uint64(delta)
+	return Access{Addr: r.prev, Size: uint32(size), Kind: Kind(kind)}, nil
+}
+
+func unexpected(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// Map approximates the bucket addresses a map[string]int lookup reads.
+// Buckets are 8 entries of bucketSize bytes, laid out after the map header,
+// and the table doubles when the average load passes 6.5 like the classic
+// Go map.
+type Map struct {
+	base    uint64
+	seed    maphash.Seed
+	keys    map[string]bool
+	buckets uint64
+}
+
+const bucketSize = 8 + 8*16 + 8*8 + 8 // tophash, string keys, int values, overflow
+
+// NewMap returns a model of the map m points to, m is only used as base address.
+func NewMap(m unsafe.Pointer) *Map {
+	return &Map{
+		base:    uint64(uintptr(m)),
+		seed:    maphash.MakeSeed(),
+		keys:    make(map[string]bool),
+		buckets: 1,
+	}
+}
+
+// Access returns the approximate bucket read when looking up key.
+func (m *Map) Access(key string) Access {
+	if !m.keys[key] {
+		m.keys[key] = true
+		for float64(len(m.keys)) > 6.5*float64(m.buckets) {
+			m.buckets *= 2
+		}
+	}
+
+	b := maphash.String(m.seed, key) & (m.buckets - 1)
+	return Access{Addr: m.base + b*bucketSize, Size: bucketSize, Kind: MapBucket}
+}
@@ -0,0 +1,96 @@
+package addrtrace
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+func TestRoundTrip(t *testing.T) {
+	accesses := []Access{
+		{Addr: 0xc000010000, Size: 1, Kind: Active},
+		{Addr: 0xc000014020, Size: 16, Kind: CountryHeader},
+		{Addr: 0x4b1e20, Size: 2, Kind: CountryBytes}, // lower than previous
+		{Addr: 0xc000100000, Size: bucketSize, Kind: MapBucket},
+	}
+
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, a := range accesses {
+		if err := w.Write(a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 8 header bytes, small records.
+	if size := buf.Len(); size > 8+len(accesses)*8 {
+		t.Fatalf("trace too big: %d bytes", size)
+	}
+
+	r, err := NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, expected := range accesses {
+		a, err := r.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a != expected {
+			t.Fatalf("%d: expected %+v, got %+v", i, expected, a)
+		}
+	}
+
+	if _, err := r.Read(); err != io.EOF {
+		t.Fatalf("expected EOF, got %v", err)
+	}
+}
+
+func TestBadHeader(t *testing.T) {
+	for _, data := range []string{"", "CCTR", "NOTTRACE", "CCTRACE\x07"} {
+		_, err := NewReader(strings.NewReader(data))
+		if !errors.Is(err, ErrBadHeader) {
+			t.Errorf("%q: expected ErrBadHeader, got %v", data, err)
+		}
+	}
+}
+
+func TestTruncated(t *testing.T) {
+	r, err := NewReader(strings.NewReader(magic + "\x01\x00"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.Read(); err != io.ErrUnexpectedEOF {
+		t.Fatalf("expected ErrUnexpectedEOF, got %v", err)
+	}
+}
+
+func TestMap(t *testing.T) {
+	var x [1 << 16]byte
+	m := NewMap(unsafe.Pointer(&x))
+
+	a := m.Access("AD")
+	if a.Addr != uint64(uintptr(unsafe.Pointer(&x))) || a.Kind != MapBucket {
+		t.Fatalf("single bucket: got %+v", a)
+	}
+	if b := m.Access("AD"); b != a {
+		t.Fatalf("same key: expected %+v, got %+v", a, b)
+	}
+
+	for c := 'A'; c <= 'Z'; c++ {
+		m.Access(string(c) + "X")
+	}
+	if m.buckets != 8 {
+		t.Fatalf("27 keys: expected 8 buckets, got %d", m.buckets)
+	}
+}
@@ -1,29 +1,87 @@
 package users
 
-import "testing"
+import (
+	"testing"
+	"unsafe"
+
+	"users/dataset"
+	"users/dataset/datasettest"
+	"users/perfcount"
+)
 
 var users []User
 
 func init() {
-	const size = 10_000
-	countries := []string{
-		"AD",
-		"BB",
-		"CA",
-		"DK",
-	}
-	users = make([]User, size)
-	for i := 0; i < size; i++ {
-		users[i].Active = i%5 > 0 // 20% non active
-		users[i].Country = countries[i%len(countries)]
-	}
+	users = MustGenerate(dataset.Default())
 }
 
 func BenchmarkCountryCount(b *testing.B) {
+	c := perfcount.Start(b)
 	for i := 0; i < b.N; i++ {
 		m := CountryCount(users)
 		if m == nil {
 			b.Fatal(m)
 		}
 	}
+	c.Report()
+}
+
+func BenchmarkCountryCountParallel(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		m := CountryCountParallel(users, 0)
+		if m == nil {
+			b.Fatal(m)
+		}
+	}
+}
+
+// userPtrs returns pointers to users, in order.
+func userPtrs(users []User, order []int) []*User {
+	ptrs := make([]*User, len(order))
+	for i, j := range order {
+		ptrs[i] = &users[j]
+	}
+	return ptrs
+}
+
+func BenchmarkCountryCountRandom(b *testing.B) {
+	order := dataset.Permutation(len(users), 1)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m := CountryCountOrder(users, order)
+		if m == nil {
+			b.Fatal(m)
+		}
+	}
+}
+
+func BenchmarkCountryCountStrided(b *testing.B) {
+	order := dataset.Strided(len(users), 16)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m := CountryCountOrder(users, order)
+		if m == nil {
+			b.Fatal(m)
+		}
+	}
+}
+
+func BenchmarkCountryCountPtr(b *testing.B) {
+	ptrs := userPtrs(users, dataset.Permutation(len(users), 1))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m := CountryCountPtr(ptrs)
+		if m == nil {
+			b.Fatal(m)
+		}
+	}
+}
+
+func BenchmarkCountryCountSweep(b *testing.B) {
+	// CountryCount reads Active and Country.
+	touched := int64(unsafe.Sizeof(User{}.Active) + unsafe.Sizeof(User{}.Country))
+	datasettest.Sweep(b, int64(unsafe.Sizeof(User{})), touched, func(cfg dataset.Config) func() map[string]int {
+		users := MustGenerate(cfg)
+		return func() map[string]int { return CountryCount(users) }
+	})
 }
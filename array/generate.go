@@ -0,0 +1,17 @@
+package users
+
+import "users/dataset"
+
+// MustGenerate returns users generated from cfg, icons are filled with
+// cfg.FillIcon. It panics if cfg is invalid, use it in benchmark setup.
+func MustGenerate(cfg dataset.Config) []User {
+	records := dataset.MustGenerate(cfg)
+	users := make([]User, len(records))
+	for i, r := range records {
+		users[i].Login = r.Login
+		users[i].Active = r.Active
+		users[i].Country = r.Country
+		cfg.FillIcon(r, users[i].Icon[:])
+	}
+	return users
+}
@@ -1 +0,0 @@
-array/bench_test.go
\ No newline at end of file
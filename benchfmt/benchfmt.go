@@ -0,0 +1,247 @@
+// Package benchfmt parses the output of "go test -bench".
+package benchfmt
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Metric is a single measurement, e.g. 63774 ns/op.
+type Metric struct {
+	Value float64
+	Unit  string
+}
+
+// Result is a single benchmark line.
+type Result struct {
+	Name       string // e.g. BenchmarkCountryCount or BenchmarkSweep/users=1024
+	Procs      int    // GOMAXPROCS suffix, 1 if there's no suffix
+	Pkg        string // from the last "pkg:" line
+	Iterations int
+	Metrics    []Metric
+}
+
+// Metric returns the value of unit and whether it was found.
+func (r Result) Metric(unit string) (float64, bool) {
+	for _, m := range r.Metrics {
+		if m.Unit == unit {
+			return m.Value, true
+		}
+	}
+
+	return 0, false
+}
+
+// Report is a parsed benchmark output.
+type Report struct {
+	Config  map[string]string // goos, goarch, pkg, cpu ...
+	Results []Result
+}
+
+// Parse parses benchmark output from r.
+// Lines that are not configuration or benchmark results are ignored.
+func Parse(r io.Reader) (*Report, error) {
+	rep := Report{Config: make(map[string]string)}
+	s := bufio.NewScanner(r)
+	lnum := 0
+	for s.Scan() {
+		lnum++
+		line := strings.TrimSpace(s.Text())
+		if strings.HasPrefix(line, "Benchmark") {
+			res, ok, err := parseResult(line)
+			if err != nil {
+				return nil, fmt.Errorf("%d: %w", lnum, err)
+			}
+			if ok {
+				res.Pkg = rep.Config["pkg"]
+				rep.Results = append(rep.Results, res)
+			}
+			continue
+		}
+
+		if key, val, ok := parseConfig(line); ok {
+			rep.Config[key] = val
+		}
+	}
+
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	return &rep, nil
+}
+
+// parseResult parses a benchmark line.
+// It returns false for lines starting with Benchmark that have no results,
+// such as the name printed before a benchmark log.
+func parseResult(line string) (Result, bool, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return Result{}, false, nil
+	}
+
+	n, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return Result{}, false, nil
+	}
+
+	if len(fields)%2 != 0 {
+		return Result{}, false, fmt.Errorf("%q: unpaired metric", line)
+	}
+
+	res := Result{Iterations: n}
+	res.Name, res.Procs = splitProcs(fields[0])
+	for i := 2; i < len(fields); i += 2 {
+		v, err := strconv.ParseFloat(fields[i], 64)
+		if err != nil {
+			return Result{}, false, fmt.Errorf("%q: bad value %q", line, fields[i])
+		}
+		res.Metrics = append(res.Metrics, Metric{v, fields[i+1]})
+	}
+
+	return res, true, nil
+}
+
+// splitProcs splits "BenchmarkCountryCount-12" to "BenchmarkCountryCount" and 12.
+func splitProcs(name string) (string, int) {
+	i := strings.LastIndexByte(name, '-')
+	if i == -1 {
+		return name, 1
+	}
+
+	procs, err := strconv.Atoi(name[i+1:])
+	if err != nil || procs < 1 {
+		return name, 1
+	}
+
+	return name[:i], procs
+}
+
+// parseConfig parses "key: value" lines such as "cpu: 12th Gen Intel(R) Core(TM) i7-1255U".
+func parseConfig(line string) (string, string, bool) {
+	i := strings.Index(line, ":")
+	if i < 1 {
+		return "", "", false
+	}
+
+	key := line[:i]
+	for _, r := range key {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return "", "", false
+		}
+	}
+
+	return key, strings.TrimSpace(line[i+1:]), true
+}
+
+// Stats are summary statistics of one metric of one benchmark.
+type Stats struct {
+	Pkg    string
+	Name   string
+	Procs  int
+	Unit   string
+	N      int
+	Mean   float64
+	Median float64
+	StdDev float64 // sample standard deviation
+	Min    float64
+	Max    float64
+}
+
+// Stats returns statistics per package, benchmark and unit, in order of first
+// appearance.
+func (r *Report) Stats() []Stats {
+	type key struct {
+		pkg   string
+		name  string
+		procs int
+		unit  string
+	}
+
+	var keys []key
+	values := make(map[key][]float64)
+	for _, res := range r.Results {
+		for _, m := range res.Metrics {
+			k := key{res.Pkg, res.Name, res.Procs, m.Unit}
+			if _, ok := values[k]; !ok {
+				keys = append(keys, k)
+			}
+			values[k] = append(values[k], m.Value)
+		}
+	}
+
+	stats := make([]Stats, 0, len(keys))
+	for _, k := range keys {
+		s := Summarize(values[k])
+		s.Pkg, s.Name, s.Procs, s.Unit = k.pkg, k.name, k.procs, k.unit
+		stats = append(stats, s)
+	}
+
+	return stats
+}
+
+// Summarize returns statistics of values, Pkg, Name, Procs and Unit are left empty.
+func Summarize(values []float64) Stats {
+	s := Stats{N: len(values)}
+	if s.N == 0 {
+		return s
+	}
+
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+
+	s.Min, s.Max = sorted[0], sorted[s.N-1]
+	if s.N%2 == 1 {
+		s.Median = sorted[s.N/2]
+	} else {
+		s.Median = (sorted[s.N/2-1] + sorted[s.N/2]) / 2
+	}
+
+	total := 0.0
+	for _, v := range values {
+		total += v
+	}
+	s.Mean = total / float64(s.N)
+
+	if s.N > 1 {
+		sq := 0.0
+		for _, v := range values {
+			d := v - s.Mean
+			sq += d * d
+		}
+		s.StdDev = math.Sqrt(sq / float64(s.N-1))
+	}
+
+	return s
+}
+
+// tTable is the two sided 95% critical value of Student's t distribution,
+// indexed by degrees of freedom.
+var tTable = []float64{
+	0, 12.706, 4.303, 3.182, 2.776, 2.571, 2.447, 2.365, 2.306, 2.262,
+	2.228, 2.201, 2.179, 2.160, 2.145, 2.131, 2.120, 2.110, 2.101, 2.093,
+	2.086, 2.080, 2.074, 2.069, 2.064, 2.060, 2.056, 2.052, 2.048, 2.045,
+	2.042,
+}
+
+// CI95 returns the 95% confidence interval of the mean.
+// With less than two values the interval is just the mean.
+func (s Stats) CI95() (lo, hi float64) {
+	if s.N < 2 {
+		return s.Mean, s.Mean
+	}
+
+	t := 1.960 // normal approximation for large samples
+	if df := s.N - 1; df < len(tTable) {
+		t = tTable[df]
+	}
+
+	d := t * s.StdDev / math.Sqrt(float64(s.N))
+	return s.Mean - d, s.Mean + d
+}
@@ -0,0 +1,158 @@
+package benchfmt
+
+import (
+	"math"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParseFile(t *testing.T) {
+	file, err := os.Open("../array-bench.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	rep, err := Parse(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cpu := rep.Config["cpu"]; cpu != "12th Gen Intel(R) Core(TM) i7-1255U" {
+		t.Fatalf("cpu: got %q", cpu)
+	}
+
+	if n := len(rep.Results); n != 5 {
+		t.Fatalf("expected 5 results, got %d", n)
+	}
+
+	r := rep.Results[0]
+	if r.Name != "BenchmarkCountryCount" || r.Procs != 12 || r.Pkg != "users" || r.Iterations != 4442 {
+		t.Fatalf("bad result: %+v", r)
+	}
+
+	if v, ok := r.Metric("ns/op"); !ok || v != 2630315 {
+		t.Fatalf("ns/op: got %v (%v)", v, ok)
+	}
+}
+
+const benchmem = `goos: linux
+pkg: users/slice
+BenchmarkCountryCount-12    	  191288	     62885 ns/op	     336 B/op	       2 allocs/op
+BenchmarkCountryCount-12    	  193185	     63890 ns/op	     336 B/op	       2 allocs/op
+BenchmarkSweep/users=1024-12	   1000	      1.5 ns/user	  24.00 B/user
+BenchmarkSweep/users=1024-12
+BenchmarkNoProcs	      10	       100 ns/op
+`
+
+func TestParseMetrics(t *testing.T) {
+	rep, err := Parse(strings.NewReader(benchmem))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := len(rep.Results); n != 4 {
+		t.Fatalf("expected 4 results, got %d", n)
+	}
+
+	r := rep.Results[0]
+	if len(r.Metrics) != 3 || r.Metrics[2] != (Metric{2, "allocs/op"}) {
+		t.Fatalf("bad metrics: %+v", r.Metrics)
+	}
+
+	r = rep.Results[2]
+	if r.Name != "BenchmarkSweep/users=1024" || r.Procs != 12 {
+		t.Fatalf("bad sub benchmark: %+v", r)
+	}
+	if v, _ := r.Metric("B/user"); v != 24 {
+		t.Fatalf("B/user: got %v", v)
+	}
+
+	r = rep.Results[3]
+	if r.Name != "BenchmarkNoProcs" || r.Procs != 1 {
+		t.Fatalf("bad result: %+v", r)
+	}
+}
+
+func TestParseError(t *testing.T) {
+	_, err := Parse(strings.NewReader("BenchmarkX-12 10 1 ns/op 2\n"))
+	if err == nil {
+		t.Fatal("no error")
+	}
+}
+
+func TestStats(t *testing.T) {
+	rep, err := Parse(strings.NewReader(benchmem))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stats := rep.Stats()
+	if len(stats) != 6 {
+		t.Fatalf("expected 6 stats, got %d", len(stats))
+	}
+
+	s := stats[0]
+	if s.Name != "BenchmarkCountryCount" || s.Unit != "ns/op" || s.N != 2 {
+		t.Fatalf("bad stats: %+v", s)
+	}
+	if s.Mean != 63387.5 || s.Min != 62885 || s.Max != 63890 || s.Median != 63387.5 {
+		t.Fatalf("bad stats: %+v", s)
+	}
+}
+
+func TestStatsPkg(t *testing.T) {
+	text := `pkg: users/array
+BenchmarkCountryCount-12 100 1000 ns/op
+pkg: users/slice
+BenchmarkCountryCount-12 100 10 ns/op
+`
+	rep, err := Parse(strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stats := rep.Stats()
+	if len(stats) != 2 {
+		t.Fatalf("expected 2 stats, got %d", len(stats))
+	}
+	for i, expected := range []Stats{
+		{Pkg: "users/array", Name: "BenchmarkCountryCount", Procs: 12, Unit: "ns/op", N: 1, Mean: 1000},
+		{Pkg: "users/slice", Name: "BenchmarkCountryCount", Procs: 12, Unit: "ns/op", N: 1, Mean: 10},
+	} {
+		s := stats[i]
+		if s.Pkg != expected.Pkg || s.Name != expected.Name || s.Procs != expected.Procs || s.N != expected.N || s.Mean != expected.Mean {
+			t.Fatalf("%d: expected %+v, got %+v", i, expected, s)
+		}
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	s := Summarize([]float64{4, 1, 3, 2, 5})
+	if s.Mean != 3 || s.Median != 3 || s.Min != 1 || s.Max != 5 {
+		t.Fatalf("bad stats: %+v", s)
+	}
+
+	if math.Abs(s.StdDev-math.Sqrt(2.5)) > 1e-9 {
+		t.Fatalf("stddev: got %v", s.StdDev)
+	}
+
+	if s := Summarize(nil); s.N != 0 || s.Mean != 0 {
+		t.Fatalf("empty: %+v", s)
+	}
+}
+
+func TestCI95(t *testing.T) {
+	s := Summarize([]float64{4, 1, 3, 2, 5})
+	lo, hi := s.CI95()
+	d := 2.776 * math.Sqrt(2.5) / math.Sqrt(5)
+	if math.Abs(lo-(3-d)) > 1e-9 || math.Abs(hi-(3+d)) > 1e-9 {
+		t.Fatalf("got [%v, %v], expected ±%v", lo, hi, d)
+	}
+
+	s = Summarize([]float64{7})
+	if lo, hi := s.CI95(); lo != 7 || hi != 7 {
+		t.Fatalf("single value: got [%v, %v]", lo, hi)
+	}
+}
@@ -0,0 +1,88 @@
+// Package bitset provides ActiveSet, a packed replacement for a per-user Active bool.
+package bitset
+
+import "math/bits"
+
+const wordSize = 64
+
+// ActiveSet is a fixed size set of bits, bit i is the Active flag of user i.
+type ActiveSet struct {
+	words []uint64
+	size  int
+}
+
+// New returns an ActiveSet of size bits, all cleared.
+func New(size int) *ActiveSet {
+	return &ActiveSet{
+		words: make([]uint64, (size+wordSize-1)/wordSize),
+		size:  size,
+	}
+}
+
+// FromBools returns an ActiveSet with bit i set if active[i] is true.
+func FromBools(active []bool) *ActiveSet {
+	s := New(len(active))
+	for i, a := range active {
+		if a {
+			s.Set(i)
+		}
+	}
+
+	return s
+}
+
+// Len returns the number of bits in s.
+func (s *ActiveSet) Len() int {
+	return s.size
+}
+
+// Set sets bit i.
+func (s *ActiveSet) Set(i int) {
+	s.check(i)
+	s.words[i/wordSize] |= 1 << (i % wordSize)
+}
+
+// Clear clears bit i.
+func (s *ActiveSet) Clear(i int) {
+	s.check(i)
+	s.words[i/wordSize] &^= 1 << (i % wordSize)
+}
+
+// Test reports if bit i is set.
+func (s *ActiveSet) Test(i int) bool {
+	s.check(i)
+	return s.words[i/wordSize]&(1<<(i%wordSize)) != 0
+}
+
+// Count returns the number of set bits.
+func (s *ActiveSet) Count() int {
+	n := 0
+	for _, w := range s.words {
+		n += bits.OnesCount64(w)
+	}
+
+	return n
+}
+
+// Iterate calls fn with the index of every set bit, in increasing order.
+func (s *ActiveSet) Iterate(fn func(i int)) {
+	for wi, w := range s.words {
+		base := wi * wordSize
+		for w != 0 {
+			fn(base + bits.TrailingZeros64(w))
+			w &= w - 1 // clear lowest set bit
+		}
+	}
+}
+
+// Words returns the underlying words, bit i is bit i%64 of word i/64.
+// Bits past Len are always cleared.
+func (s *ActiveSet) Words() []uint64 {
+	return s.words
+}
+
+func (s *ActiveSet) check(i int) {
+	if i < 0 || i >= s.size {
+		panic("bitset: index out of range")
+	}
+}
@@ -0,0 +1,60 @@
+package bitset
+
+import "testing"
+
+func TestActiveSet(t *testing.T) {
+	s := New(130)
+	for _, i := range []int{0, 3, 63, 64, 129} {
+		s.Set(i)
+	}
+	s.Clear(3)
+
+	if n := s.Count(); n != 4 {
+		t.Fatalf("count: expected 4, got %d", n)
+	}
+
+	if !s.Test(64) || s.Test(3) || s.Test(65) {
+		t.Fatal("bad Test results")
+	}
+
+	var got []int
+	s.Iterate(func(i int) {
+		got = append(got, i)
+	})
+	expected := []int{0, 63, 64, 129}
+	if len(got) != len(expected) {
+		t.Fatalf("iterate: expected %v, got %v", expected, got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Fatalf("iterate: expected %v, got %v", expected, got)
+		}
+	}
+}
+
+func TestFromBools(t *testing.T) {
+	active := make([]bool, 100)
+	for i := range active {
+		active[i] = i%5 > 0
+	}
+
+	s := FromBools(active)
+	if n := s.Count(); n != 80 {
+		t.Fatalf("expected 80, got %d", n)
+	}
+	for i, a := range active {
+		if s.Test(i) != a {
+			t.Fatalf("%d: expected %v", i, a)
+		}
+	}
+}
+
+func TestOutOfRange(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("no panic")
+		}
+	}()
+
+	New(10).Set(10)
+}
@@ -0,0 +1,245 @@
+// Package cachesim simulates a multi level set associative cache.
+package cachesim
+
+import (
+	"fmt"
+	"io"
+
+	"users/topology"
+)
+
+// Policy is a replacement policy.
+type Policy int
+
+const (
+	// LRU evicts the least recently used line.
+	LRU Policy = iota
+	// PLRU evicts using one MRU bit per line (bit pseudo LRU), it works with
+	// any number of ways.
+	PLRU
+)
+
+func (p Policy) String() string {
+	switch p {
+	case LRU:
+		return "LRU"
+	case PLRU:
+		return "PLRU"
+	}
+	return fmt.Sprintf("Policy(%d)", int(p))
+}
+
+// Inclusion is how levels share lines.
+type Inclusion int
+
+const (
+	// Inclusive: every line in a level is also in all the levels below it.
+	// Evicting a line from a level evicts it from the levels above.
+	Inclusive Inclusion = iota
+	// Exclusive: a line is in at most one level. Lines are filled to the
+	// first level and victims move down one level.
+	Exclusive
+)
+
+func (i Inclusion) String() string {
+	switch i {
+	case Inclusive:
+		return "inclusive"
+	case Exclusive:
+		return "exclusive"
+	}
+	return fmt.Sprintf("Inclusion(%d)", int(i))
+}
+
+// LevelConfig configures a single cache level.
+type LevelConfig struct {
+	Name     string
+	Size     int64 // bytes
+	Ways     int
+	LineSize int // bytes, must be the same for all levels
+	Policy   Policy
+}
+
+// Stats are the counters of a single level.
+type Stats struct {
+	Name   string
+	Hits   uint64
+	Misses uint64
+}
+
+// HitRate returns the fraction of accesses to the level that hit.
+func (s Stats) HitRate() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+// Hierarchy is a multi level cache, level 0 is closest to the CPU.
+type Hierarchy struct {
+	levels    []*level
+	inclusion Inclusion
+	lineShift uint
+	accesses  uint64
+}
+
+// New returns an empty cache hierarchy.
+func New(inclusion Inclusion, configs ...LevelConfig) (*Hierarchy, error) {
+	if len(configs) == 0 {
+		return nil, fmt.Errorf("no levels")
+	}
+
+	lineSize := configs[0].LineSize
+	if lineSize <= 0 || lineSize&(lineSize-1) != 0 {
+		return nil, fmt.Errorf("%s: line size %d is not a power of 2", configs[0].Name, lineSize)
+	}
+
+	h := Hierarchy{inclusion: inclusion}
+	for lineSize > 1 {
+		h.lineShift++
+		lineSize >>= 1
+	}
+
+	for _, cfg := range configs {
+		if cfg.LineSize != configs[0].LineSize {
+			return nil, fmt.Errorf("%s: line size %d != %d", cfg.Name, cfg.LineSize, configs[0].LineSize)
+		}
+
+		l, err := newLevel(cfg)
+		if err != nil {
+			return nil, err
+		}
+		h.levels = append(h.levels, l)
+	}
+
+	return &h, nil
+}
+
+// FromCaches returns a hierarchy of the data and unified caches seen by
+// CPU 0, using policy for every level.
+func FromCaches(caches []topology.Cache, inclusion Inclusion, policy Policy) (*Hierarchy, error) {
+	var configs []LevelConfig
+	for _, c := range topology.DataCaches(topology.ForCPU(caches, 0)) {
+		configs = append(configs, LevelConfig{
+			Name:     c.Name,
+			Size:     int64(c.Ways) * int64(c.Sets) * int64(c.LineSize),
+			Ways:     c.Ways,
+			LineSize: c.LineSize,
+			Policy:   policy,
+		})
+	}
+
+	return New(inclusion, configs...)
+}
+
+// FromLscpu returns a hierarchy configured from "lscpu -C" output (see cache.txt).
+func FromLscpu(r io.Reader, inclusion Inclusion, policy Policy) (*Hierarchy, error) {
+	caches, err := topology.ParseLscpu(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return FromCaches(caches, inclusion, policy)
+}
+
+// Access simulates reading addr. It returns the index of the level that hit,
+// or the number of levels if the line came from memory.
+func (h *Hierarchy) Access(addr uint64) int {
+	h.accesses++
+	tag := addr >> h.lineShift
+
+	hit := len(h.levels)
+	for i, l := range h.levels {
+		if l.lookup(tag) {
+			l.stats.Hits++
+			hit = i
+			break
+		}
+		l.stats.Misses++
+	}
+
+	switch h.inclusion {
+	case Inclusive:
+		h.fillInclusive(tag, hit)
+	case Exclusive:
+		h.fillExclusive(tag, hit)
+	}
+
+	return hit
+}
+
+// AccessRange simulates reading size bytes starting at addr, one access per line.
+func (h *Hierarchy) AccessRange(addr uint64, size int) {
+	if size <= 0 {
+		return
+	}
+
+	first := addr >> h.lineShift
+	last := (addr + uint64(size) - 1) >> h.lineShift
+	for line := first; line <= last; line++ {
+		h.Access(line << h.lineShift)
+	}
+}
+
+// fillInclusive brings tag to all levels above hit.
+func (h *Hierarchy) fillInclusive(tag uint64, hit int) {
+	for i := hit - 1; i >= 0; i-- {
+		victim, evicted := h.levels[i].insert(tag)
+		if !evicted {
+			continue
+		}
+		// Keep inclusion: the victim can't stay in the levels above i.
+		for j := 0; j < i; j++ {
+			h.levels[j].remove(victim)
+		}
+	}
+}
+
+// fillExclusive moves tag to the first level, victims cascade down and the
+// victim of the last level is dropped.
+func (h *Hierarchy) fillExclusive(tag uint64, hit int) {
+	if hit == 0 {
+		return
+	}
+
+	if hit < len(h.levels) {
+		h.levels[hit].remove(tag)
+	}
+
+	for _, l := range h.levels {
+		victim, evicted := l.insert(tag)
+		if !evicted {
+			return
+		}
+		tag = victim
+	}
+}
+
+// Stats returns the counters of every level.
+func (h *Hierarchy) Stats() []Stats {
+	stats := make([]Stats, len(h.levels))
+	for i, l := range h.levels {
+		stats[i] = l.stats
+	}
+
+	return stats
+}
+
+// Accesses returns the number of simulated accesses.
+func (h *Hierarchy) Accesses() uint64 {
+	return h.accesses
+}
+
+// MemoryAccesses returns the number of accesses that missed every level.
+func (h *Hierarchy) MemoryAccesses() uint64 {
+	return h.levels[len(h.levels)-1].stats.Misses
+}
+
+// Reset clears the counters, keeping cache content.
+func (h *Hierarchy) Reset() {
+	h.accesses = 0
+	for _, l := range h.levels {
+		l.stats = Stats{Name: l.stats.Name}
+	}
+}
@@ -0,0 +1,178 @@
+package cachesim
+
+import (
+	"os"
+	"testing"
+)
+
+// twoLevels returns a 2 set, 2 way L1 and a 4 set, 2 way L2 with 64 byte lines.
+func twoLevels(t *testing.T, inclusion Inclusion, policy Policy) *Hierarchy {
+	h, err := New(
+		inclusion,
+		LevelConfig{Name: "L1", Size: 2 * 2 * 64, Ways: 2, LineSize: 64, Policy: policy},
+		LevelConfig{Name: "L2", Size: 4 * 2 * 64, Ways: 2, LineSize: 64, Policy: policy},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+func TestAccessSameLine(t *testing.T) {
+	h := twoLevels(t, Inclusive, LRU)
+	if lvl := h.Access(0); lvl != 2 {
+		t.Fatalf("first access: expected memory, got level %d", lvl)
+	}
+	if lvl := h.Access(63); lvl != 0 {
+		t.Fatalf("same line: expected L1, got level %d", lvl)
+	}
+
+	stats := h.Stats()
+	if stats[0].Hits != 1 || stats[0].Misses != 1 || stats[1].Misses != 1 {
+		t.Fatalf("bad stats: %+v", stats)
+	}
+	if h.MemoryAccesses() != 1 || h.Accesses() != 2 {
+		t.Fatalf("bad totals: %d memory, %d accesses", h.MemoryAccesses(), h.Accesses())
+	}
+}
+
+func TestLRU(t *testing.T) {
+	h := twoLevels(t, Inclusive, LRU)
+	// Lines 0, 2, 4 map to L1 set 0.
+	h.Access(0 * 64)
+	h.Access(2 * 64)
+	h.Access(0 * 64) // 2 is now LRU
+	h.Access(4 * 64) // evicts 2 from L1
+
+	if lvl := h.Access(0 * 64); lvl != 0 {
+		t.Fatalf("line 0: expected L1, got %d", lvl)
+	}
+	if lvl := h.Access(2 * 64); lvl != 1 {
+		t.Fatalf("line 2: expected L2, got %d", lvl)
+	}
+}
+
+func TestPLRU(t *testing.T) {
+	h := twoLevels(t, Inclusive, PLRU)
+	h.Access(0 * 64)
+	h.Access(2 * 64) // all MRU bits set, only line 2 keeps its bit
+	h.Access(4 * 64) // evicts 0
+
+	if lvl := h.Access(2 * 64); lvl != 0 {
+		t.Fatalf("line 2: expected L1, got %d", lvl)
+	}
+	if lvl := h.Access(0 * 64); lvl != 1 {
+		t.Fatalf("line 0: expected L2, got %d", lvl)
+	}
+}
+
+func TestInclusiveBackInvalidation(t *testing.T) {
+	h := twoLevels(t, Inclusive, LRU)
+	// Lines 0, 4, 8 map to L2 set 0 and L1 set 0.
+	h.Access(0 * 64)
+	h.Access(4 * 64)
+	h.Access(8 * 64) // evicts 0 from L2, so it must leave L1 too
+
+	if lvl := h.Access(0 * 64); lvl != 2 {
+		t.Fatalf("line 0: expected memory, got %d", lvl)
+	}
+}
+
+func TestExclusive(t *testing.T) {
+	h := twoLevels(t, Exclusive, LRU)
+	h.Access(0 * 64)
+	h.Access(2 * 64)
+	h.Access(4 * 64) // 0 moves to L2
+
+	if lvl := h.Access(0 * 64); lvl != 1 {
+		t.Fatalf("line 0: expected L2, got %d", lvl)
+	}
+
+	// 0 moved back to L1, 2 (LRU) moved to L2, capacity is L1+L2.
+	for _, line := range []uint64{0, 4} {
+		if lvl := h.Access(line * 64); lvl != 0 {
+			t.Fatalf("line %d: expected L1, got %d", line, lvl)
+		}
+	}
+	if lvl := h.Access(2 * 64); lvl != 1 {
+		t.Fatalf("line 2: expected L2, got %d", lvl)
+	}
+}
+
+func TestAccessRange(t *testing.T) {
+	h := twoLevels(t, Inclusive, LRU)
+	h.AccessRange(60, 8) // crosses a line
+	if n := h.Accesses(); n != 2 {
+		t.Fatalf("expected 2 accesses, got %d", n)
+	}
+
+	h.Reset()
+	if n := h.Accesses(); n != 0 || h.Stats()[0].Hits != 0 {
+		t.Fatal("reset didn't clear stats")
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	cases := [][]LevelConfig{
+		nil,
+		{{Name: "L1", Size: 128, Ways: 2, LineSize: 48}},
+		{{Name: "L1", Size: 100, Ways: 2, LineSize: 64}},
+		{{Name: "L1", Size: 128, Ways: 0, LineSize: 64}},
+		{{Name: "L1", Size: 128, Ways: 2, LineSize: 64}, {Name: "L2", Size: 256, Ways: 2, LineSize: 128}},
+	}
+	for _, configs := range cases {
+		if _, err := New(Inclusive, configs...); err == nil {
+			t.Errorf("%+v: no error", configs)
+		}
+	}
+}
+
+func TestFromLscpu(t *testing.T) {
+	file, err := os.Open("../cache.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	h, err := FromLscpu(file, Inclusive, PLRU)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stats := h.Stats()
+	names := []string{"L1d", "L2", "L3"}
+	if len(stats) != len(names) {
+		t.Fatalf("expected %d levels, got %+v", len(names), stats)
+	}
+	for i, name := range names {
+		if stats[i].Name != name {
+			t.Fatalf("level %d: expected %s, got %s", i, name, stats[i].Name)
+		}
+	}
+
+	// A 32K scan fits L1d (48K): second pass always hits.
+	for pass := 0; pass < 2; pass++ {
+		h.Reset()
+		for addr := uint64(0); addr < 32<<10; addr += 8 {
+			h.Access(addr)
+		}
+	}
+	if s := h.Stats()[0]; s.Misses != 0 {
+		t.Fatalf("second pass: expected no L1d misses, got %+v", s)
+	}
+}
+
+func BenchmarkAccess(b *testing.B) {
+	h, err := New(
+		Inclusive,
+		LevelConfig{Name: "L1d", Size: 48 << 10, Ways: 12, LineSize: 64},
+		LevelConfig{Name: "L2", Size: 1280 << 10, Ways: 10, LineSize: 64},
+	)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for i := 0; i < b.N; i++ {
+		h.Access(uint64(i) * 64 % (4 << 20))
+	}
+}
@@ -0,0 +1,132 @@
+package cachesim
+
+import "fmt"
+
+type way struct {
+	tag   uint64
+	valid bool
+	used  uint64 // LRU: last access time, PLRU: MRU bit (0 or 1)
+}
+
+type level struct {
+	sets   [][]way
+	policy Policy
+	clock  uint64
+	stats  Stats
+}
+
+func newLevel(cfg LevelConfig) (*level, error) {
+	if cfg.Ways <= 0 {
+		return nil, fmt.Errorf("%s: bad number of ways: %d", cfg.Name, cfg.Ways)
+	}
+
+	setSize := int64(cfg.Ways) * int64(cfg.LineSize)
+	if cfg.Size <= 0 || cfg.Size%setSize != 0 {
+		return nil, fmt.Errorf("%s: size %d is not a multiple of ways*line size (%d)", cfg.Name, cfg.Size, setSize)
+	}
+
+	l := level{
+		sets:   make([][]way, cfg.Size/setSize),
+		policy: cfg.Policy,
+		stats:  Stats{Name: cfg.Name},
+	}
+	for i := range l.sets {
+		l.sets[i] = make([]way, cfg.Ways)
+	}
+
+	return &l, nil
+}
+
+func (l *level) set(tag uint64) []way {
+	return l.sets[tag%uint64(len(l.sets))]
+}
+
+// lookup reports if tag is cached, updating replacement state on a hit.
+func (l *level) lookup(tag uint64) bool {
+	set := l.set(tag)
+	for i := range set {
+		if set[i].valid && set[i].tag == tag {
+			l.touch(set, i)
+			return true
+		}
+	}
+
+	return false
+}
+
+// insert adds tag, it returns the evicted tag if a valid line was replaced.
+// Inserting a tag that's already cached only updates replacement state.
+func (l *level) insert(tag uint64) (uint64, bool) {
+	set := l.set(tag)
+	for i := range set {
+		if set[i].valid && set[i].tag == tag {
+			l.touch(set, i)
+			return 0, false
+		}
+	}
+
+	i := l.victim(set)
+	victim, evicted := set[i].tag, set[i].valid
+	set[i] = way{tag: tag, valid: true}
+	l.touch(set, i)
+	return victim, evicted
+}
+
+func (l *level) remove(tag uint64) {
+	set := l.set(tag)
+	for i := range set {
+		if set[i].valid && set[i].tag == tag {
+			set[i] = way{}
+			return
+		}
+	}
+}
+
+func (l *level) touch(set []way, i int) {
+	switch l.policy {
+	case LRU:
+		l.clock++
+		set[i].used = l.clock
+	case PLRU:
+		set[i].used = 1
+		for j := range set {
+			if set[j].used == 0 {
+				return
+			}
+		}
+		// All bits set, clear all but the one just used.
+		for j := range set {
+			if j != i {
+				set[j].used = 0
+			}
+		}
+	}
+}
+
+// victim returns the way to replace: an invalid way if there is one,
+// otherwise the one chosen by the policy.
+func (l *level) victim(set []way) int {
+	for i := range set {
+		if !set[i].valid {
+			return i
+		}
+	}
+
+	switch l.policy {
+	case PLRU:
+		for i := range set {
+			if set[i].used == 0 {
+				return i
+			}
+		}
+		return 0
+	default:
+		oldest := 0
+		for i := range set {
+			if set[i].used < set[oldest].used {
+				oldest = i
+			}
+		}
+		return oldest
+	}
+}
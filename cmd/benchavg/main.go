@@ -0,0 +1,85 @@
+// Command benchavg prints statistics of "go test -bench" output.
+//
+//	benchavg [file ...]
+//
+// With no files, benchavg reads standard input ("-"). Statistics are per
+// file, results of different files are never combined.
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"users/benchfmt"
+)
+
+func main() {
+	args := os.Args[1:]
+	if len(args) > 0 && (args[0] == "-h" || args[0] == "-help" || args[0] == "--help") {
+		fmt.Fprintf(os.Stderr, "usage: %s [file ...]\n", filepath.Base(os.Args[0]))
+		os.Exit(2)
+	}
+
+	var files []fileStats
+	if len(args) == 0 {
+		rep, err := benchfmt.Parse(os.Stdin)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %s\n", err)
+			os.Exit(1)
+		}
+		files = append(files, fileStats{"-", rep.Stats()})
+	}
+
+	for _, path := range args {
+		rep, err := parseFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %s\n", err)
+			os.Exit(1)
+		}
+		files = append(files, fileStats{path, rep.Stats()})
+	}
+
+	if err := printStats(os.Stdout, files); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func parseFile(path string) (*benchfmt.Report, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	rep, err := benchfmt.Parse(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return rep, nil
+}
+
+// fileStats are the statistics of a single benchmark output.
+type fileStats struct {
+	file  string
+	stats []benchfmt.Stats
+}
+
+func printStats(w io.Writer, files []fileStats) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "file\tpkg\tname\tunit\tn\tmean\tmedian\tstddev\tmin\tmax\t")
+	for _, f := range files {
+		for _, s := range f.stats {
+			fmt.Fprintf(
+				tw, "%s\t%s\t%s-%d\t%s\t%d\t%.2f\t%.2f\t%.2f\t%.2f\t%.2f\t\n",
+				f.file, s.Pkg, s.Name, s.Procs, s.Unit, s.N, s.Mean, s.Median, s.StdDev, s.Min, s.Max,
+			)
+		}
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,172 @@
+// Command cachebench builds and runs the benchmarks of one or more User layouts.
+//
+// Each layout test binary is built with "go test -c" into a temporary
+// directory, the working tree is never changed.
+//
+//	cachebench [flags] [layout ...]
+//
+// With no layout, all layouts are run.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// layout is a package implementing User and CountryCount.
+type layout struct {
+	name string
+	dir  string // relative to module root
+}
+
+var layouts = []layout{
+	{"array", "array"},
+	{"slice", "slice"},
+	{"columnar", "columnar"},
+	{"hotcold", "hotcold"},
+	{"interned", "interned"},
+	{"ptrslice", "ptrslice"},
+}
+
+var options struct {
+	bench     string
+	benchtime string
+	count     int
+	perf      bool
+	events    string
+	list      bool
+}
+
+func main() {
+	flag.StringVar(&options.bench, "bench", ".", "benchmarks to run (regexp)")
+	flag.StringVar(&options.benchtime, "benchtime", "10s", "run time per benchmark")
+	flag.IntVar(&options.count, "count", 5, "run each benchmark count times")
+	flag.BoolVar(&options.perf, "perf", false, "run under perf stat")
+	flag.StringVar(&options.events, "events", "cache-misses", "perf events (with -perf)")
+	flag.BoolVar(&options.list, "list", false, "list layouts and exit")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] [layout ...]\n", filepath.Base(os.Args[0]))
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if options.list {
+		for _, l := range layouts {
+			fmt.Println(l.name)
+		}
+		return
+	}
+
+	selected, err := selectLayouts(flag.Args())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		os.Exit(1)
+	}
+
+	if err := run(selected); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func selectLayouts(names []string) ([]layout, error) {
+	if len(names) == 0 {
+		return layouts, nil
+	}
+
+	var selected []layout
+	for _, name := range names {
+		l, ok := findLayout(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown layout: %q", name)
+		}
+		selected = append(selected, l)
+	}
+
+	return selected, nil
+}
+
+func findLayout(name string) (layout, bool) {
+	for _, l := range layouts {
+		if l.name == name {
+			return l, true
+		}
+	}
+
+	return layout{}, false
+}
+
+func run(selected []layout) error {
+	root, err := moduleRoot()
+	if err != nil {
+		return err
+	}
+
+	tmpDir, err := os.MkdirTemp("", "cachebench-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	for _, l := range selected {
+		exe := filepath.Join(tmpDir, l.name+".test")
+		if err := build(root, l, exe); err != nil {
+			return fmt.Errorf("%s: build: %w", l.name, err)
+		}
+
+		fmt.Printf("layout: %s\n", l.name)
+		if err := bench(filepath.Join(root, l.dir), exe); err != nil {
+			return fmt.Errorf("%s: bench: %w", l.name, err)
+		}
+	}
+
+	return nil
+}
+
+// moduleRoot returns the directory of the main module.
+func moduleRoot() (string, error) {
+	out, err := exec.Command("go", "env", "GOMOD").Output()
+	if err != nil {
+		return "", fmt.Errorf("go env: %w", err)
+	}
+
+	gomod := strings.TrimSpace(string(out))
+	if gomod == "" || gomod == os.DevNull {
+		return "", fmt.Errorf("not inside a Go module")
+	}
+
+	return filepath.Dir(gomod), nil
+}
+
+func build(root string, l layout, exe string) error {
+	cmd := exec.Command("go", "test", "-c", "-o", exe, "./"+l.dir)
+	cmd.Dir = root
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+func bench(dir, exe string) error {
+	args := []string{
+		"-test.run", "^$",
+		"-test.bench", options.bench,
+		"-test.benchtime", options.benchtime,
+		"-test.count", fmt.Sprint(options.count),
+	}
+
+	var cmd *exec.Cmd
+	if options.perf {
+		perfArgs := append([]string{"stat", "-e", options.events, exe}, args...)
+		cmd = exec.Command("perf", perfArgs...)
+	} else {
+		cmd = exec.Command(exe, args...)
+	}
+	cmd.Dir = dir // tests expect to run in their package directory
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
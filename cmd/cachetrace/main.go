@@ -0,0 +1,184 @@
+// Command cachetrace records address traces of CountryCount and runs them
+// through the cache simulator.
+//
+//	cachetrace record [-n count] [-o file] layout
+//	cachetrace simulate [-lscpu file] [-policy lru|plru] [-exclusive] file
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"users/addrtrace"
+	array "users/array"
+	"users/cachesim"
+	"users/dataset"
+	slice "users/slice"
+	"users/topology"
+)
+
+// recorders write a CountryCount trace of the users in cfg to w.
+var recorders = map[string]func(w *addrtrace.Writer, cfg dataset.Config){
+	"array": func(w *addrtrace.Writer, cfg dataset.Config) {
+		array.CountryCountTrace(array.MustGenerate(cfg), w)
+	},
+	"slice": func(w *addrtrace.Writer, cfg dataset.Config) {
+		slice.CountryCountTrace(slice.MustGenerate(cfg), w)
+	},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "record":
+		err = record(os.Args[2:])
+	case "simulate":
+		err = simulate(os.Args[2:])
+	default:
+		usage()
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	name := filepath.Base(os.Args[0])
+	fmt.Fprintf(os.Stderr, "usage: %s record [-n count] [-o file] layout\n", name)
+	fmt.Fprintf(os.Stderr, "       %s simulate [-lscpu file] [-policy lru|plru] [-exclusive] file\n", name)
+	os.Exit(2)
+}
+
+func record(args []string) error {
+	fs := flag.NewFlagSet("record", flag.ExitOnError)
+	count := fs.Int("n", 10_000, "number of users")
+	out := fs.String("o", "countrycount.trace", "output file")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		usage()
+	}
+
+	rec, ok := recorders[fs.Arg(0)]
+	if !ok {
+		return fmt.Errorf("unknown layout: %q", fs.Arg(0))
+	}
+
+	file, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w, err := addrtrace.NewWriter(file)
+	if err != nil {
+		return err
+	}
+
+	cfg := dataset.Default()
+	cfg.Size = *count
+	rec(w, cfg)
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Printf("%s: %d accesses\n", *out, w.Count())
+	return file.Close()
+}
+
+var policies = map[string]cachesim.Policy{
+	"lru":  cachesim.LRU,
+	"plru": cachesim.PLRU,
+}
+
+func simulate(args []string) error {
+	fs := flag.NewFlagSet("simulate", flag.ExitOnError)
+	lscpuFile := fs.String("lscpu", "", "read caches from lscpu -C output (e.g. cache.txt) instead of sysfs")
+	policyName := fs.String("policy", "plru", "replacement policy: lru or plru")
+	exclusive := fs.Bool("exclusive", false, "simulate exclusive caches (default inclusive)")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		usage()
+	}
+
+	policy, ok := policies[*policyName]
+	if !ok {
+		return fmt.Errorf("unknown policy: %q", *policyName)
+	}
+
+	inclusion := cachesim.Inclusive
+	if *exclusive {
+		inclusion = cachesim.Exclusive
+	}
+
+	caches, err := loadCaches(*lscpuFile)
+	if err != nil {
+		return err
+	}
+
+	h, err := cachesim.FromCaches(caches, inclusion, policy)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	r, err := addrtrace.NewReader(file)
+	if err != nil {
+		return err
+	}
+
+	for {
+		a, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		h.AccessRange(a.Addr, int(a.Size))
+	}
+
+	printStats(h, inclusion, policy)
+	return nil
+}
+
+func loadCaches(lscpuFile string) ([]topology.Cache, error) {
+	if lscpuFile == "" {
+		return topology.Discover()
+	}
+
+	file, err := os.Open(lscpuFile)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return topology.ParseLscpu(file)
+}
+
+func printStats(h *cachesim.Hierarchy, inclusion cachesim.Inclusion, policy cachesim.Policy) {
+	fmt.Printf("%s, %s: %d accesses, %d from memory\n", inclusion, policy, h.Accesses(), h.MemoryAccesses())
+	tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "level\thits\tmisses\thit rate")
+	for _, s := range h.Stats() {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.2f%%\n", s.Name, s.Hits, s.Misses, 100*s.HitRate())
+	}
+	tw.Flush()
+}
@@ -0,0 +1,132 @@
+// Command layoutcmp compares benchmark and perf stat results of User layouts.
+//
+//	layoutcmp [-format text|markdown|csv] [-o dir] file file [file ...]
+//
+// Each file holds "go test -bench" output, optionally followed by a
+// "perf stat" block (see array-perf.txt). The first file is the baseline,
+// ratios are baseline/file so 2.00 means the file is twice as fast or has
+// half the cache misses per benchmark iteration.
+//
+// Text and markdown tables are printed to standard output. With -format csv
+// every table is written to its own file in dir: bench.csv and perf.csv.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"users/benchfmt"
+	"users/perfstat"
+)
+
+// result is the parsed content of a single file.
+type result struct {
+	name  string // file name without extension
+	bench *benchfmt.Report
+	perf  *perfstat.Stats // nil if the file has no perf stat output
+}
+
+func main() {
+	format := flag.String("format", "text", "output format: text, markdown or csv")
+	outDir := flag.String("o", ".", "directory for csv files, one per table")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] file file [file ...]\n", filepath.Base(os.Args[0]))
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() < 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	write, ok := writers[*format]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "error: unknown format: %q\n", *format)
+		os.Exit(2)
+	}
+
+	var results []result
+	for _, path := range flag.Args() {
+		r, err := parseFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %s\n", err)
+			os.Exit(1)
+		}
+		results = append(results, r)
+	}
+
+	tables := []*table{benchTable(results)}
+	if t := perfTable(results); len(t.rows) > 0 {
+		tables = append(tables, t)
+	}
+
+	if *format == "csv" {
+		for _, t := range tables {
+			path := filepath.Join(*outDir, t.name+".csv")
+			if err := writeFile(path, t, write); err != nil {
+				fmt.Fprintf(os.Stderr, "error: %s\n", err)
+				os.Exit(1)
+			}
+			fmt.Println(path)
+		}
+		return
+	}
+
+	for i, t := range tables {
+		if i > 0 {
+			fmt.Println()
+		}
+		if err := write(os.Stdout, t); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %s\n", err)
+			os.Exit(1)
+		}
+	}
+}
+
+// writeFile writes t to a new file at path.
+func writeFile(path string, t *table, write func(io.Writer, *table) error) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := write(file, t); err != nil {
+		file.Close()
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	return file.Close()
+}
+
+func parseFile(path string) (result, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return result{}, err
+	}
+
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	r := result{name: name}
+	r.bench, err = benchfmt.Parse(strings.NewReader(string(data)))
+	if err != nil {
+		return result{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	r.perf, err = perfstat.Parse(strings.NewReader(string(data)))
+	if err != nil && !errors.Is(err, perfstat.ErrNoStats) {
+		return result{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return r, nil
+}
+
+var writers = map[string]func(io.Writer, *table) error{
+	"text":     writeText,
+	"markdown": writeMarkdown,
+	"csv":      writeCSV,
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+
+	"users/benchfmt"
+	"users/perfstat"
+)
+
+// benchTable compares every benchmark metric to the same metric in the first
+// result. Metrics are matched by package too, unless the first result has the
+// benchmark in a single package (files of different layout packages).
+func benchTable(results []result) *table {
+	t := table{
+		name:   "bench",
+		title:  "Benchmarks",
+		header: []string{"file", "pkg", "benchmark", "unit", "n", "mean", "ci95 low", "ci95 high", "ratio"},
+	}
+
+	type key struct {
+		pkg   string
+		name  string
+		procs int
+		unit  string
+	}
+	base := make(map[key]benchfmt.Stats)
+	byName := make(map[key][]benchfmt.Stats) // key without pkg
+	if len(results) > 0 {
+		for _, s := range results[0].bench.Stats() {
+			base[key{s.Pkg, s.Name, s.Procs, s.Unit}] = s
+			k := key{"", s.Name, s.Procs, s.Unit}
+			byName[k] = append(byName[k], s)
+		}
+	}
+
+	for _, r := range results {
+		for _, s := range r.bench.Stats() {
+			b, ok := base[key{s.Pkg, s.Name, s.Procs, s.Unit}]
+			if same := byName[key{"", s.Name, s.Procs, s.Unit}]; !ok && len(same) == 1 {
+				b, ok = same[0], true
+			}
+
+			ratio := "-"
+			if ok && s.Mean != 0 {
+				ratio = fmt.Sprintf("%.2f", b.Mean/s.Mean)
+			}
+
+			lo, hi := s.CI95()
+			pkg := s.Pkg
+			if pkg == "" {
+				pkg = "-"
+			}
+			t.rows = append(t.rows, []string{
+				r.name,
+				pkg,
+				fmt.Sprintf("%s-%d", s.Name, s.Procs),
+				s.Unit,
+				fmt.Sprint(s.N),
+				fmt.Sprintf("%.2f", s.Mean),
+				fmt.Sprintf("%.2f", lo),
+				fmt.Sprintf("%.2f", hi),
+				ratio,
+			})
+		}
+	}
+
+	return &t
+}
+
+// perfTable compares every perf counter per benchmark iteration to the same
+// counter (PMU and event) in the first result. Iterations are the total of
+// the file's benchmark results. perf also counts the runs go test does to
+// pick b.N, so per op values are a slight overestimate.
+func perfTable(results []result) *table {
+	t := table{
+		name:   "perf",
+		title:  "Performance counters",
+		header: []string{"file", "pmu", "event", "value", "iterations", "per op", "running", "ratio"},
+	}
+
+	type key struct {
+		pmu   string
+		event string
+	}
+	base := make(map[key]float64) // per op
+	for i, r := range results {
+		if r.perf == nil {
+			continue
+		}
+
+		iters := 0
+		for _, res := range r.bench.Results {
+			iters += res.Iterations
+		}
+
+		for _, c := range r.perf.Counters {
+			k := key{c.PMU, c.Event}
+			value, perOp, ratio := c.Status, "-", "-"
+			if c.Status == perfstat.Counted {
+				value = fmt.Sprintf("%.0f", c.Value)
+				if iters > 0 {
+					op := c.Value / float64(iters)
+					perOp = fmt.Sprintf("%.2f", op)
+					if i == 0 {
+						base[k] = op
+					}
+					if b, ok := base[k]; ok && op != 0 {
+						ratio = fmt.Sprintf("%.2f", b/op)
+					}
+				}
+			}
+
+			pmu := c.PMU
+			if pmu == "" {
+				pmu = "-"
+			}
+			t.rows = append(t.rows, []string{
+				r.name,
+				pmu,
+				c.Event,
+				value,
+				fmt.Sprint(iters),
+				perOp,
+				fmt.Sprintf("%.2f%%", c.Running),
+				ratio,
+			})
+		}
+	}
+
+	return &t
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"users/benchfmt"
+)
+
+func loadResults(t *testing.T, paths ...string) []result {
+	var results []result
+	for _, path := range paths {
+		r, err := parseFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		results = append(results, r)
+	}
+
+	return results
+}
+
+func TestBenchTable(t *testing.T) {
+	results := loadResults(t, "../../array-bench.txt", "../../slice-bench.txt")
+	tbl := benchTable(results)
+	if len(tbl.rows) != 2 {
+		t.Fatalf("expected 2 rows, got %d", len(tbl.rows))
+	}
+
+	row := tbl.rows[1]
+	if row[0] != "slice-bench" || row[1] != "users" || row[5] != "64015.40" || row[8] != "41.83" {
+		t.Fatalf("bad row: %v", row)
+	}
+}
+
+func TestBenchTablePkg(t *testing.T) {
+	parse := func(name, text string) result {
+		rep, err := benchfmt.Parse(strings.NewReader(text))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result{name: name, bench: rep}
+	}
+	results := []result{
+		parse("old", "pkg: users/array\nBenchmarkCountryCount-12 100 1000 ns/op\npkg: users/slice\nBenchmarkCountryCount-12 100 10 ns/op\n"),
+		parse("new", "pkg: users/array\nBenchmarkCountryCount-12 100 500 ns/op\npkg: users/slice\nBenchmarkCountryCount-12 100 20 ns/op\n"),
+	}
+
+	tbl := benchTable(results)
+	if len(tbl.rows) != 4 {
+		t.Fatalf("expected 4 rows, got %d", len(tbl.rows))
+	}
+	for i, expected := range [][2]string{{"users/array", "1000.00"}, {"users/slice", "10.00"}, {"users/array", "500.00"}, {"users/slice", "20.00"}} {
+		if row := tbl.rows[i]; row[1] != expected[0] || row[5] != expected[1] {
+			t.Fatalf("%d: bad row: %v", i, row)
+		}
+	}
+	if ratio := tbl.rows[2][8]; ratio != "2.00" {
+		t.Fatalf("array: expected ratio 2.00, got %s", ratio)
+	}
+	if ratio := tbl.rows[3][8]; ratio != "0.50" {
+		t.Fatalf("slice: expected ratio 0.50, got %s", ratio)
+	}
+}
+
+func TestPerfTable(t *testing.T) {
+	results := loadResults(t, "../../array-perf.txt", "../../slice-perf.txt")
+	tbl := perfTable(results)
+	if len(tbl.rows) != 4 {
+		t.Fatalf("expected 4 rows, got %d", len(tbl.rows))
+	}
+
+	row := tbl.rows[2]
+	if row[1] != "cpu_core" || row[3] != "1828311" || row[4] != "951108" || row[5] != "1.92" || row[7] != "398309.12" {
+		t.Fatalf("bad row: %v", row)
+	}
+
+	if tbl := perfTable(loadResults(t, "../../array-bench.txt")); len(tbl.rows) != 0 {
+		t.Fatalf("expected no rows, got %v", tbl.rows)
+	}
+}
+
+func TestWriters(t *testing.T) {
+	tbl := &table{
+		title:  "T",
+		header: []string{"a", "b"},
+		rows:   [][]string{{"1", "x,y"}},
+	}
+
+	cases := map[string]string{
+		"text":     "T:\na  b\n1  x,y\n",
+		"markdown": "**T**\n\n| a | b |\n|---|---|\n| 1 | x,y |\n",
+		"csv":      "a,b\n1,\"x,y\"\n",
+	}
+	for format, expected := range cases {
+		var buf bytes.Buffer
+		if err := writers[format](&buf, tbl); err != nil {
+			t.Fatal(err)
+		}
+		if got := buf.String(); got != expected {
+			t.Errorf("%s: expected %q, got %q", format, expected, got)
+		}
+	}
+}
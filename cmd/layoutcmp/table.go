@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+type table struct {
+	name   string // file name for csv output
+	title  string
+	header []string
+	rows   [][]string
+}
+
+func writeText(w io.Writer, t *table) error {
+	fmt.Fprintf(w, "%s:\n", t.title)
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(t.header, "\t"))
+	for _, row := range t.rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+
+	return tw.Flush()
+}
+
+func writeMarkdown(w io.Writer, t *table) error {
+	fmt.Fprintf(w, "**%s**\n\n", t.title)
+	fmt.Fprintf(w, "| %s |\n", strings.Join(t.header, " | "))
+	fmt.Fprintf(w, "|%s\n", strings.Repeat("---|", len(t.header)))
+	for _, row := range t.rows {
+		if _, err := fmt.Fprintf(w, "| %s |\n", strings.Join(row, " | ")); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeCSV writes the table without its title, w should hold only this
+// table for the output to be a valid CSV file.
+func writeCSV(w io.Writer, t *table) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(t.header); err != nil {
+		return err
+	}
+	if err := cw.WriteAll(t.rows); err != nil {
+		return err
+	}
+
+	return cw.Error()
+}
@@ -0,0 +1,101 @@
+// Command structlayout prints the memory layout of the User types.
+//
+//	structlayout [-line 64] [layout ...]
+//
+// With no layout, all layouts are printed.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+
+	array "users/array"
+	hotcold "users/hotcold"
+	interned "users/interned"
+	slice "users/slice"
+	"users/structlayout"
+)
+
+var layouts = []struct {
+	name string
+	typ  reflect.Type
+}{
+	{"array", reflect.TypeOf(array.User{})},
+	{"slice", reflect.TypeOf(slice.User{})},
+	{"hotcold", reflect.TypeOf(hotcold.Hot{})},
+	{"interned", reflect.TypeOf(interned.User{})},
+}
+
+func main() {
+	lineSize := flag.Int64("line", 64, "cache line size")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] [layout ...]\n", filepath.Base(os.Args[0]))
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	names := flag.Args()
+	if len(names) == 0 {
+		for _, l := range layouts {
+			names = append(names, l.name)
+		}
+	}
+
+	for i, name := range names {
+		typ, ok := findLayout(name)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "error: unknown layout: %q\n", name)
+			os.Exit(1)
+		}
+
+		l, err := structlayout.Inspect(typ, *lineSize)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %s\n", err)
+			os.Exit(1)
+		}
+
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("layout: %s\n", name)
+		printLayout(os.Stdout, l)
+	}
+}
+
+func findLayout(name string) (reflect.Type, bool) {
+	for _, l := range layouts {
+		if l.name == name {
+			return l.typ, true
+		}
+	}
+
+	return nil, false
+}
+
+func printLayout(w io.Writer, l structlayout.Layout) {
+	fmt.Fprintf(w, "%s: size %d, align %d, padding %d\n", l.Type, l.Size, l.Align, l.Padding)
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "offset\tsize\talign\tpad\tlines\tfield")
+	for _, f := range l.Fields {
+		lines := fmt.Sprint(f.FirstLine)
+		if f.LastLine != f.FirstLine {
+			lines = fmt.Sprintf("%d-%d", f.FirstLine, f.LastLine)
+		}
+		fmt.Fprintf(tw, "%d\t%d\t%d\t%d\t%s\t%s %s\n", f.Offset, f.Size, f.Align, f.Padding, lines, f.Name, f.Type)
+	}
+	tw.Flush()
+
+	order, size := l.Suggest()
+	if size < l.Size {
+		fmt.Fprintf(w, "suggested order: %s (size %d, saves %d)\n", strings.Join(order, ", "), size, l.Size-size)
+	} else {
+		fmt.Fprintln(w, "field order is optimal")
+	}
+}
@@ -0,0 +1,114 @@
+// Command sweepplot plots the ns/user of the CountryCountSweep benchmarks
+// against the working set size, with the cache sizes marked. The working set
+// is users times B/user, the bytes of the fields CountryCount reads per user.
+//
+//	go test -bench Sweep ./... | sweepplot [-format ascii|svg] [-cpu n] [-lscpu file] [-o file]
+//	sweepplot [flags] file ...
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"users/benchfmt"
+	"users/topology"
+)
+
+func main() {
+	format := flag.String("format", "ascii", "output format: ascii or svg")
+	cpu := flag.Int("cpu", 0, "use the caches of this CPU (hybrid CPUs have different P and E core caches)")
+	lscpuFile := flag.String("lscpu", "", "read caches from lscpu -C output (e.g. cache.txt) instead of sysfs")
+	out := flag.String("o", "", "output file (default stdout)")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] [file ...]\n", filepath.Base(os.Args[0]))
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	render, ok := renderers[*format]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "error: unknown format: %q\n", *format)
+		os.Exit(2)
+	}
+
+	results, err := readResults(flag.Args())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		os.Exit(1)
+	}
+
+	series := sweepSeries(results)
+	if len(series) == 0 {
+		fmt.Fprintln(os.Stderr, "error: no sweep results (users=N sub benchmarks with ns/user and B/user)")
+		os.Exit(1)
+	}
+
+	caches, err := loadCaches(*lscpuFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: no cache sizes: %s\n", err)
+	}
+
+	w := os.Stdout
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %s\n", err)
+			os.Exit(1)
+		}
+		defer file.Close()
+		w = file
+	}
+
+	if err := render(w, series, topology.DataCaches(topology.ForCPU(caches, *cpu))); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+var renderers = map[string]func(io.Writer, []series, []topology.Cache) error{
+	"ascii": renderASCII,
+	"svg":   renderSVG,
+}
+
+func readResults(paths []string) ([]benchfmt.Result, error) {
+	if len(paths) == 0 {
+		rep, err := benchfmt.Parse(os.Stdin)
+		if err != nil {
+			return nil, err
+		}
+		return rep.Results, nil
+	}
+
+	var results []benchfmt.Result
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		rep, err := benchfmt.Parse(file)
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		results = append(results, rep.Results...)
+	}
+
+	return results, nil
+}
+
+func loadCaches(lscpuFile string) ([]topology.Cache, error) {
+	if lscpuFile == "" {
+		return topology.Discover()
+	}
+
+	file, err := os.Open(lscpuFile)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return topology.ParseLscpu(file)
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"strings"
+
+	"users/topology"
+)
+
+// scale maps bytes (log 2) and ns/user (linear) to plot coordinates.
+type scale struct {
+	minX, maxX float64 // log2 bytes
+	maxY       float64
+}
+
+func newScale(all []series, caches []topology.Cache) scale {
+	s := scale{minX: math.Inf(1), maxX: math.Inf(-1)}
+	for _, ser := range all {
+		for _, p := range ser.points {
+			s.minX = math.Min(s.minX, math.Log2(p.bytes))
+			s.maxX = math.Max(s.maxX, math.Log2(p.bytes))
+			s.maxY = math.Max(s.maxY, p.nsPerUser)
+		}
+	}
+	for _, c := range caches {
+		s.minX = math.Min(s.minX, math.Log2(float64(c.Size)))
+		s.maxX = math.Max(s.maxX, math.Log2(float64(c.Size)))
+	}
+
+	s.minX, s.maxX = math.Floor(s.minX), math.Ceil(s.maxX)
+	if s.minX == s.maxX {
+		s.minX, s.maxX = s.minX-1, s.maxX+1
+	}
+	s.maxY *= 1.1
+	if s.maxY == 0 {
+		s.maxY = 1
+	}
+
+	return s
+}
+
+// x returns the position of bytes in [0, width].
+func (s scale) x(bytes, width float64) float64 {
+	return (math.Log2(bytes) - s.minX) / (s.maxX - s.minX) * width
+}
+
+// y returns the position of ns in [0, height], 0 is the top.
+func (s scale) y(ns, height float64) float64 {
+	return height - ns/s.maxY*height
+}
+
+var markers = []rune("*o+x#@%&")
+
+const (
+	asciiWidth  = 72
+	asciiHeight = 20
+)
+
+func renderASCII(w io.Writer, all []series, caches []topology.Cache) error {
+	s := newScale(all, caches)
+	grid := make([][]rune, asciiHeight)
+	for i := range grid {
+		grid[i] = []rune(strings.Repeat(" ", asciiWidth))
+	}
+
+	for _, c := range caches {
+		col := int(math.Round(s.x(float64(c.Size), asciiWidth-1)))
+		for row := 1; row < asciiHeight; row++ {
+			grid[row][col] = ':'
+		}
+		for i, r := range c.Name {
+			if col+i < asciiWidth {
+				grid[0][col+i] = r
+			}
+		}
+	}
+
+	for i, ser := range all {
+		m := markers[i%len(markers)]
+		for _, p := range ser.points {
+			col := int(math.Round(s.x(p.bytes, asciiWidth-1)))
+			row := int(math.Round(s.y(p.nsPerUser, asciiHeight-1)))
+			grid[row][col] = m
+		}
+	}
+
+	fmt.Fprintln(w, "ns/user")
+	for row, line := range grid {
+		label := ""
+		switch row {
+		case 0:
+			label = fmt.Sprintf("%.1f", s.maxY)
+		case asciiHeight - 1:
+			label = "0"
+		}
+		fmt.Fprintf(w, "%8s |%s\n", label, strings.TrimRight(string(line), " "))
+	}
+	fmt.Fprintf(w, "%8s +%s\n", "", strings.Repeat("-", asciiWidth))
+
+	lo, hi := formatBytes(math.Exp2(s.minX)), formatBytes(math.Exp2(s.maxX))
+	pad := asciiWidth - len(lo) - len(hi)
+	if pad < 1 {
+		pad = 1
+	}
+	fmt.Fprintf(w, "%8s  %s%s%s (working set)\n", "", lo, strings.Repeat(" ", pad), hi)
+
+	for i, ser := range all {
+		fmt.Fprintf(w, "  %c %s\n", markers[i%len(markers)], ser.name)
+	}
+
+	return nil
+}
+
+var colors = []string{"#1f77b4", "#ff7f0e", "#2ca02c", "#d62728", "#9467bd", "#8c564b", "#e377c2", "#7f7f7f"}
+
+const (
+	svgWidth   = 800
+	svgHeight  = 450
+	marginLeft = 70
+	marginTop  = 30
+	marginEnd  = 120 // right, room for the legend
+	marginBot  = 50
+)
+
+func renderSVG(w io.Writer, all []series, caches []topology.Cache) error {
+	s := newScale(all, caches)
+	pw, ph := float64(svgWidth-marginLeft-marginEnd), float64(svgHeight-marginTop-marginBot)
+	px := func(bytes float64) float64 { return marginLeft + s.x(bytes, pw) }
+	py := func(ns float64) float64 { return marginTop + s.y(ns, ph) }
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="sans-serif" font-size="12">`+"\n", svgWidth, svgHeight)
+	fmt.Fprintf(&b, `<rect width="100%%" height="100%%" fill="white"/>`+"\n")
+
+	// Axes
+	x0, y0 := float64(marginLeft), marginTop+ph
+	fmt.Fprintf(&b, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="black"/>`+"\n", x0, y0, x0+pw, y0)
+	fmt.Fprintf(&b, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="black"/>`+"\n", x0, float64(marginTop), x0, y0)
+	for e := s.minX; e <= s.maxX; e += 2 {
+		x := px(math.Exp2(e))
+		fmt.Fprintf(&b, `<text x="%.1f" y="%.1f" text-anchor="middle">%s</text>`+"\n", x, y0+18, html.EscapeString(formatBytes(math.Exp2(e))))
+	}
+	for i := 0; i <= 4; i++ {
+		ns := s.maxY * float64(i) / 4
+		fmt.Fprintf(&b, `<text x="%.1f" y="%.1f" text-anchor="end">%.1f</text>`+"\n", x0-6, py(ns)+4, ns)
+	}
+	fmt.Fprintf(&b, `<text x="%.1f" y="%d" text-anchor="middle">working set</text>`+"\n", x0+pw/2, svgHeight-10)
+	fmt.Fprintf(&b, `<text x="15" y="%.1f" transform="rotate(-90 15 %.1f)" text-anchor="middle">ns/user</text>`+"\n", marginTop+ph/2, marginTop+ph/2)
+
+	// Caches
+	for _, c := range caches {
+		x := px(float64(c.Size))
+		fmt.Fprintf(&b, `<line x1="%.1f" y1="%d" x2="%.1f" y2="%.1f" stroke="gray" stroke-dasharray="4 4"/>`+"\n", x, marginTop, x, y0)
+		fmt.Fprintf(&b, `<text x="%.1f" y="%d" text-anchor="middle" fill="gray">%s %s</text>`+"\n", x, marginTop-8, html.EscapeString(c.Name), html.EscapeString(formatBytes(float64(c.Size))))
+	}
+
+	// Series
+	for i, ser := range all {
+		color := colors[i%len(colors)]
+		coords := make([]string, len(ser.points))
+		for j, p := range ser.points {
+			coords[j] = fmt.Sprintf("%.1f,%.1f", px(p.bytes), py(p.nsPerUser))
+		}
+		fmt.Fprintf(&b, `<polyline points="%s" fill="none" stroke="%s" stroke-width="2"/>`+"\n", strings.Join(coords, " "), color)
+		for _, p := range ser.points {
+			fmt.Fprintf(&b, `<circle cx="%.1f" cy="%.1f" r="3" fill="%s"/>`+"\n", px(p.bytes), py(p.nsPerUser), color)
+		}
+
+		ly := float64(marginTop + 20*i)
+		lx := float64(svgWidth - marginEnd + 15)
+		fmt.Fprintf(&b, `<rect x="%.1f" y="%.1f" width="12" height="12" fill="%s"/>`+"\n", lx, ly, color)
+		fmt.Fprintf(&b, `<text x="%.1f" y="%.1f">%s</text>`+"\n", lx+18, ly+10, html.EscapeString(ser.name))
+	}
+
+	b.WriteString("</svg>\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func formatBytes(n float64) string {
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB"}
+	i := 0
+	for n >= 1024 && i < len(units)-1 {
+		n /= 1024
+		i++
+	}
+
+	return fmt.Sprintf("%g %s", math.Round(n*10)/10, units[i])
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"users/benchfmt"
+	"users/topology"
+)
+
+const sweep = `pkg: users/slice
+BenchmarkCountryCountSweep/users=256-12     3   2907 ns/op   64.00 B/user   10.00 ns/user
+BenchmarkCountryCountSweep/users=256-12     3   2907 ns/op   64.00 B/user   12.00 ns/user
+BenchmarkCountryCountSweep/users=65536-12   3 879610 ns/op   64.00 B/user   13.42 ns/user
+BenchmarkCountryCount-12                    3  64000 ns/op
+pkg: users/hotcold
+BenchmarkCountryCountSweep/users=1024-12    3   1575 ns/op    4.000 B/user   1.43 ns/user
+`
+
+func parseSweep(t *testing.T) []series {
+	rep, err := benchfmt.Parse(strings.NewReader(sweep))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sweepSeries(rep.Results)
+}
+
+func TestSweepSeries(t *testing.T) {
+	all := parseSweep(t)
+	if len(all) != 2 {
+		t.Fatalf("expected 2 series, got %+v", all)
+	}
+
+	s := all[0]
+	if s.name != "slice" || len(s.points) != 2 {
+		t.Fatalf("bad series: %+v", s)
+	}
+	if p := s.points[0]; p.bytes != 256*64 || p.nsPerUser != 11 {
+		t.Fatalf("bad point: %+v", p)
+	}
+
+	if all[1].name != "hotcold" || all[1].points[0].bytes != 4096 {
+		t.Fatalf("bad series: %+v", all[1])
+	}
+}
+
+func testCaches(t *testing.T) []topology.Cache {
+	file, err := os.Open("../../cache.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	caches, err := topology.ParseLscpu(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return topology.DataCaches(caches)
+}
+
+func TestRenderASCII(t *testing.T) {
+	var buf bytes.Buffer
+	if err := renderASCII(&buf, parseSweep(t), testCaches(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	for _, s := range []string{"L1d", "L2", "L3", "* slice", "o hotcold", "4 KiB", "16 MiB"} {
+		if !strings.Contains(out, s) {
+			t.Errorf("missing %q in\n%s", s, out)
+		}
+	}
+}
+
+func TestRenderSVG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := renderSVG(&buf, parseSweep(t), testCaches(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	if !strings.HasPrefix(out, "<svg") || !strings.HasSuffix(out, "</svg>\n") {
+		t.Fatalf("not an SVG:\n%s", out)
+	}
+	if n := strings.Count(out, "<polyline"); n != 2 {
+		t.Fatalf("expected 2 polylines, got %d", n)
+	}
+	if n := strings.Count(out, "stroke-dasharray"); n != 3 {
+		t.Fatalf("expected 3 cache lines, got %d", n)
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	cases := map[float64]string{
+		512:              "512 B",
+		48 << 10:         "48 KiB",
+		1.25 * (1 << 20): "1.3 MiB",
+		1 << 30:          "1 GiB",
+	}
+	for n, expected := range cases {
+		if s := formatBytes(n); s != expected {
+			t.Errorf("%v: expected %q, got %q", n, expected, s)
+		}
+	}
+}
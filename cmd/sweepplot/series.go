@@ -0,0 +1,108 @@
+package main
+
+import (
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"users/benchfmt"
+)
+
+// point is the mean ns/user at a working set size.
+type point struct {
+	bytes     float64 // users * B/user
+	nsPerUser float64
+}
+
+// series is the sweep of a single layout.
+type series struct {
+	name   string
+	points []point // sorted by bytes
+}
+
+// sweepSeries groups "<name>/users=N" results with ns/user and B/user
+// metrics by package and benchmark, averaging repeated runs.
+func sweepSeries(results []benchfmt.Result) []series {
+	type key struct {
+		pkg   string
+		bench string
+	}
+	type sum struct {
+		bytes float64
+		ns    float64
+		n     int
+	}
+
+	var keys []key
+	sums := make(map[key]map[int]*sum) // users -> sum
+	benches := make(map[string]map[string]bool)
+	for _, r := range results {
+		bench, users, ok := splitUsers(r.Name)
+		if !ok {
+			continue
+		}
+		ns, ok1 := r.Metric("ns/user")
+		size, ok2 := r.Metric("B/user")
+		if !ok1 || !ok2 {
+			continue
+		}
+
+		k := key{r.Pkg, bench}
+		if sums[k] == nil {
+			keys = append(keys, k)
+			sums[k] = make(map[int]*sum)
+		}
+		if benches[r.Pkg] == nil {
+			benches[r.Pkg] = make(map[string]bool)
+		}
+		benches[r.Pkg][bench] = true
+
+		s := sums[k][users]
+		if s == nil {
+			s = &sum{}
+			sums[k][users] = s
+		}
+		s.bytes += float64(users) * size
+		s.ns += ns
+		s.n++
+	}
+
+	all := make([]series, 0, len(keys))
+	for _, k := range keys {
+		name := path.Base(k.pkg)
+		if name == "." || name == "" {
+			name = "?"
+		}
+		if len(benches[k.pkg]) > 1 {
+			name += ":" + strings.TrimPrefix(k.bench, "Benchmark")
+		}
+
+		s := series{name: name}
+		for _, v := range sums[k] {
+			s.points = append(s.points, point{v.bytes / float64(v.n), v.ns / float64(v.n)})
+		}
+		sort.Slice(s.points, func(i, j int) bool {
+			return s.points[i].bytes < s.points[j].bytes
+		})
+		all = append(all, s)
+	}
+
+	return all
+}
+
+// splitUsers splits "BenchmarkCountryCountSweep/users=1024" to its benchmark
+// name and user count.
+func splitUsers(name string) (string, int, bool) {
+	i := strings.LastIndex(name, "/users=")
+	if i == -1 {
+		return "", 0, false
+	}
+
+	n, err := strconv.Atoi(name[i+len("/users="):])
+	if err != nil {
+		return "", 0, false
+	}
+
+	return name[:i], n, true
+}
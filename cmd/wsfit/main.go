@@ -0,0 +1,158 @@
+// Command wsfit reports which cache levels the working set of CountryCount fits in.
+//
+//	wsfit [-n count] [-lscpu file] [layout ...]
+//
+// With no layout, all layouts are analyzed.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"text/tabwriter"
+
+	array "users/array"
+	hotcold "users/hotcold"
+	interned "users/interned"
+	slice "users/slice"
+	"users/topology"
+	"users/workingset"
+)
+
+const iconSize = 128 * 128
+
+// layouts are the User layouts CountryCount scans, it reads Active and Country.
+var layouts = []workingset.Layout{
+	{
+		Name:   "array",
+		Type:   reflect.TypeOf(array.User{}),
+		Fields: []string{"Active", "Country"},
+	},
+	{
+		Name:     "slice",
+		Type:     reflect.TypeOf(slice.User{}),
+		Fields:   []string{"Active", "Country"},
+		Indirect: iconSize,
+	},
+	{
+		Name:     "hotcold",
+		Type:     reflect.TypeOf(hotcold.Hot{}),
+		Fields:   []string{"Active", "Country"},
+		Indirect: int64(reflect.TypeOf(hotcold.Cold{}).Size()) + iconSize,
+	},
+	{
+		Name:     "interned",
+		Type:     reflect.TypeOf(interned.User{}),
+		Fields:   []string{"Active", "Country"},
+		Indirect: iconSize,
+	},
+}
+
+func main() {
+	count := flag.Int("n", 10_000, "number of users")
+	cpu := flag.Int("cpu", 0, "use the caches of this CPU (hybrid CPUs have different P and E core caches)")
+	lscpuFile := flag.String("lscpu", "", "read caches from lscpu -C output (e.g. cache.txt) instead of sysfs")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [flags] [layout ...]\n", filepath.Base(os.Args[0]))
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	caches, err := loadCaches(*lscpuFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		os.Exit(1)
+	}
+
+	selected, err := selectLayouts(flag.Args())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		os.Exit(1)
+	}
+
+	for i, l := range selected {
+		a, err := workingset.Analyze(l, *count, topology.ForCPU(caches, *cpu))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %s\n", err)
+			os.Exit(1)
+		}
+
+		if i > 0 {
+			fmt.Println()
+		}
+		printAnalysis(os.Stdout, a)
+	}
+}
+
+func loadCaches(lscpuFile string) ([]topology.Cache, error) {
+	if lscpuFile == "" {
+		return topology.Discover()
+	}
+
+	file, err := os.Open(lscpuFile)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return topology.ParseLscpu(file)
+}
+
+func selectLayouts(names []string) ([]workingset.Layout, error) {
+	if len(names) == 0 {
+		return layouts, nil
+	}
+
+	var selected []workingset.Layout
+	for _, name := range names {
+		found := false
+		for _, l := range layouts {
+			if l.Name == name {
+				selected = append(selected, l)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown layout: %q", name)
+		}
+	}
+
+	return selected, nil
+}
+
+func printAnalysis(w io.Writer, a workingset.Analysis) {
+	fmt.Fprintf(w, "layout: %s (%s, %d bytes)\n", a.Layout.Name, a.Layout.Type, a.ElemSize)
+	fmt.Fprintf(w, "users: %d\n", a.Count)
+	fmt.Fprintf(w, "touched per user: %.2f lines (%.0f bytes)\n", a.LinesPerElem, a.LinesPerElem*float64(a.LineSize))
+	fmt.Fprintf(w, "hot working set: %s\n", formatBytes(a.HotBytes))
+	fmt.Fprintf(w, "total footprint: %s\n", formatBytes(a.TotalBytes))
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "cache\tsize\tfits\thot/size")
+	for _, f := range a.Fits {
+		fits := "no"
+		if f.Fits {
+			fits = "yes"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%.2f\n", f.Cache.Name, formatBytes(f.Cache.Size), fits, f.Ratio)
+	}
+	tw.Flush()
+}
+
+func formatBytes(n int64) string {
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB"}
+	v, i := float64(n), 0
+	for v >= 1024 && i < len(units)-1 {
+		v /= 1024
+		i++
+	}
+
+	if i == 0 {
+		return fmt.Sprintf("%d B", n)
+	}
+	return fmt.Sprintf("%.1f %s", v, units[i])
+}
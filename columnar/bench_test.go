@@ -0,0 +1,48 @@
+package users
+
+import (
+	"testing"
+	"unsafe"
+
+	"users/bitset"
+	"users/dataset"
+	"users/dataset/datasettest"
+)
+
+var (
+	table  *UserTable
+	active *bitset.ActiveSet
+)
+
+func init() {
+	table = MustGenerate(dataset.Default())
+	active = bitset.FromBools(table.Active)
+}
+
+func BenchmarkCountryCount(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		m := table.CountryCount()
+		if m == nil {
+			b.Fatal(m)
+		}
+	}
+}
+
+func BenchmarkCountryCountBits(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		m := CountryCountBits(active, table.Country)
+		if m == nil {
+			b.Fatal(m)
+		}
+	}
+}
+
+func BenchmarkCountryCountSweep(b *testing.B) {
+	// The table allocates a full Icon column per user, CountryCount reads
+	// only the Active and Country columns.
+	touched := int64(unsafe.Sizeof(User{}.Active) + unsafe.Sizeof(User{}.Country))
+	datasettest.Sweep(b, int64(unsafe.Sizeof(User{})), touched, func(cfg dataset.Config) func() map[string]int {
+		table := MustGenerate(cfg)
+		return table.CountryCount
+	})
+}
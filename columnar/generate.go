@@ -0,0 +1,20 @@
+package users
+
+import "users/dataset"
+
+// MustGenerate returns a table of users generated from cfg.
+// It panics if cfg is invalid, use it in benchmark setup.
+func MustGenerate(cfg dataset.Config) *UserTable {
+	records := dataset.MustGenerate(cfg)
+	t := NewUserTable(len(records))
+	for _, r := range records {
+		u := User{
+			Login:   r.Login,
+			Active:  r.Active,
+			Country: r.Country,
+		}
+		cfg.FillIcon(r, u.Icon[:])
+		t.Append(u)
+	}
+	return t
+}
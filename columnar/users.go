@@ -0,0 +1,122 @@
+package users
+
+import (
+	"fmt"
+	"math/bits"
+
+	"users/bitset"
+)
+
+type Image [128 * 128]byte
+
+type User struct {
+	Login   string
+	Active  bool
+	Icon    Image
+	Country string
+}
+
+// UserTable stores users column by column (struct of arrays).
+// Row i is made of Active[i], Country[i], Login[i] and Icon[i].
+type UserTable struct {
+	Active  []bool
+	Country []string
+	Login   []string
+	Icon    []Image
+}
+
+// NewUserTable returns an empty table with room for size users.
+func NewUserTable(size int) *UserTable {
+	return &UserTable{
+		Active:  make([]bool, 0, size),
+		Country: make([]string, 0, size),
+		Login:   make([]string, 0, size),
+		Icon:    make([]Image, 0, size),
+	}
+}
+
+// FromUsers returns a table holding a copy of users.
+func FromUsers(users []User) *UserTable {
+	t := NewUserTable(len(users))
+	for i := range users {
+		t.Append(users[i])
+	}
+
+	return t
+}
+
+// Len returns the number of users in the table.
+func (t *UserTable) Len() int {
+	return len(t.Active)
+}
+
+// Append adds u at the end of the table.
+func (t *UserTable) Append(u User) {
+	t.Active = append(t.Active, u.Active)
+	t.Country = append(t.Country, u.Country)
+	t.Login = append(t.Login, u.Login)
+	t.Icon = append(t.Icon, u.Icon)
+}
+
+// Get returns the user at row i.
+func (t *UserTable) Get(i int) User {
+	return User{
+		Login:   t.Login[i],
+		Active:  t.Active[i],
+		Icon:    t.Icon[i],
+		Country: t.Country[i],
+	}
+}
+
+// Set replaces the user at row i with u.
+func (t *UserTable) Set(i int, u User) {
+	t.Active[i] = u.Active
+	t.Country[i] = u.Country
+	t.Login[i] = u.Login
+	t.Icon[i] = u.Icon
+}
+
+// Users returns the table as a slice of users.
+func (t *UserTable) Users() []User {
+	users := make([]User, t.Len())
+	for i := range users {
+		users[i] = t.Get(i)
+	}
+
+	return users
+}
+
+// CountryCount returns map of country to number of active users.
+// Only the Active and Country columns are read.
+func (t *UserTable) CountryCount() map[string]int {
+	counts := make(map[string]int) // country -> count
+	for i, active := range t.Active {
+		if !active {
+			continue
+		}
+		counts[t.Country[i]]++
+	}
+
+	return counts
+}
+
+// CountryCountBits returns map of country to number of active users, where
+// user i is active if bit i of active is set and its country is countries[i].
+// Inactive users are skipped a 64 bit word at a time.
+// It panics if active and countries are not the same length.
+func CountryCountBits(active *bitset.ActiveSet, countries []string) map[string]int {
+	if active.Len() != len(countries) {
+		panic(fmt.Sprintf("users: %d active bits for %d countries", active.Len(), len(countries)))
+	}
+
+	counts := make(map[string]int) // country -> count
+	for wi, w := range active.Words() {
+		row := countries[wi*64:]
+		for w != 0 {
+			counts[row[bits.TrailingZeros64(w)]]++
+			w &= w - 1 // clear lowest set bit
+		}
+	}
+
+	return counts
+}
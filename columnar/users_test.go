@@ -0,0 +1,83 @@
+package users
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"users/bitset"
+)
+
+// testUsers returns n users with every third one inactive.
+func testUsers(n int) []User {
+	users := make([]User, n)
+	for i := range users {
+		users[i] = User{
+			Login:   fmt.Sprintf("user%d", i),
+			Active:  i%3 != 0,
+			Country: []string{"AD", "BB", "CA", "DK", "EG"}[i%5],
+		}
+		users[i].Icon[0] = byte(i)
+	}
+	return users
+}
+
+// countryCount is the plain []User count the table is checked against.
+func countryCount(users []User) map[string]int {
+	counts := make(map[string]int) // country -> count
+	for _, u := range users {
+		if u.Active {
+			counts[u.Country]++
+		}
+	}
+	return counts
+}
+
+func TestFromUsers(t *testing.T) {
+	users := testUsers(100)
+	table := FromUsers(users)
+	if table.Len() != len(users) {
+		t.Fatalf("expected %d users, got %d", len(users), table.Len())
+	}
+	if out := table.Users(); !reflect.DeepEqual(out, users) {
+		t.Fatal("round trip mismatch")
+	}
+
+	expected := countryCount(users)
+	if counts := table.CountryCount(); !reflect.DeepEqual(counts, expected) {
+		t.Fatalf("expected %v, got %v", expected, counts)
+	}
+}
+
+func TestSet(t *testing.T) {
+	table := FromUsers(testUsers(3))
+	u := User{Login: "bugs", Active: true, Country: "US"}
+	u.Icon[1] = 7
+	table.Set(1, u)
+
+	if out := table.Get(1); !reflect.DeepEqual(out, u) {
+		t.Fatalf("expected %q, got %q", u.Login, out.Login)
+	}
+	if out := table.Get(2); out.Login != "user2" {
+		t.Fatalf("expected user2, got %q", out.Login)
+	}
+}
+
+func TestCountryCountBits(t *testing.T) {
+	for _, n := range []int{0, 1, 63, 64, 65, 130} {
+		table := FromUsers(testUsers(n))
+		counts := CountryCountBits(bitset.FromBools(table.Active), table.Country)
+		if expected := table.CountryCount(); !reflect.DeepEqual(counts, expected) {
+			t.Fatalf("%d users: expected %v, got %v", n, expected, counts)
+		}
+	}
+}
+
+func TestCountryCountBitsLength(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic")
+		}
+	}()
+	CountryCountBits(bitset.New(65), make([]string, 64))
+}
@@ -0,0 +1,113 @@
+// Package country interns ISO 3166 alpha-2 country codes to small integer IDs.
+package country
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ID is an interned country code.
+type ID uint8
+
+const (
+	// Unknown is the zero ID, its code is "".
+	Unknown ID = 0
+	// MaxIDs is the number of distinct IDs, including Unknown.
+	MaxIDs = 1 << 8
+)
+
+var (
+	ErrInvalidCode = errors.New("invalid ISO 3166 alpha-2 code")
+	ErrFull        = errors.New("registry is full")
+)
+
+// Registry maps country codes to IDs and back.
+// The zero value is not usable, use NewRegistry.
+type Registry struct {
+	codes []string      // id -> code
+	ids   map[string]ID // code -> id
+}
+
+// NewRegistry returns a registry holding only Unknown.
+func NewRegistry() *Registry {
+	return &Registry{
+		codes: []string{""},
+		ids:   map[string]ID{"": Unknown},
+	}
+}
+
+// Intern returns the ID of code, assigning a new one if code wasn't seen before.
+func (r *Registry) Intern(code string) (ID, error) {
+	if id, ok := r.ids[code]; ok {
+		return id, nil
+	}
+
+	if !valid(code) {
+		return Unknown, fmt.Errorf("%w: %q", ErrInvalidCode, code)
+	}
+
+	if len(r.codes) == MaxIDs {
+		return Unknown, fmt.Errorf("%w: can't intern %q", ErrFull, code)
+	}
+
+	id := ID(len(r.codes))
+	r.codes = append(r.codes, code)
+	r.ids[code] = id
+	return id, nil
+}
+
+// MustIntern is like Intern but panics on error.
+func (r *Registry) MustIntern(code string) ID {
+	id, err := r.Intern(code)
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
+
+// Lookup returns the ID of code and if it was interned.
+func (r *Registry) Lookup(code string) (ID, bool) {
+	id, ok := r.ids[code]
+	return id, ok
+}
+
+// Code returns the country code of id.
+func (r *Registry) Code(id ID) string {
+	if int(id) >= len(r.codes) {
+		return ""
+	}
+	return r.codes[id]
+}
+
+// Len returns the number of IDs in use, including Unknown.
+func (r *Registry) Len() int {
+	return len(r.codes)
+}
+
+// Counts is a count per country, indexed by ID.
+type Counts [MaxIDs]int
+
+// Map returns counts as country code -> count, skipping countries with zero count.
+func (c *Counts) Map(r *Registry) map[string]int {
+	m := make(map[string]int)
+	for id, n := range c[:r.Len()] {
+		if n > 0 {
+			m[r.codes[id]] = n
+		}
+	}
+	return m
+}
+
+// valid reports if code is two upper case ASCII letters.
+func valid(code string) bool {
+	if len(code) != 2 {
+		return false
+	}
+
+	for i := 0; i < len(code); i++ {
+		if code[i] < 'A' || code[i] > 'Z' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,72 @@
+package country
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestIntern(t *testing.T) {
+	r := NewRegistry()
+	ad := r.MustIntern("AD")
+	bb := r.MustIntern("BB")
+	if ad == bb {
+		t.Fatalf("AD and BB got same id: %d", ad)
+	}
+
+	if id := r.MustIntern("AD"); id != ad {
+		t.Fatalf("AD: expected %d, got %d", ad, id)
+	}
+
+	if code := r.Code(bb); code != "BB" {
+		t.Fatalf("expected BB, got %q", code)
+	}
+
+	if id, _ := r.Intern(""); id != Unknown {
+		t.Fatalf("expected Unknown, got %d", id)
+	}
+}
+
+func TestInternInvalid(t *testing.T) {
+	r := NewRegistry()
+	for _, code := range []string{"a", "ad", "ADD", "A1", "ÄD"} {
+		_, err := r.Intern(code)
+		if !errors.Is(err, ErrInvalidCode) {
+			t.Errorf("%q: expected ErrInvalidCode, got %v", code, err)
+		}
+	}
+}
+
+func TestInternFull(t *testing.T) {
+	r := NewRegistry()
+	for i := 0; i < MaxIDs-1; i++ {
+		code := fmt.Sprintf("%c%c", 'A'+i/26, 'A'+i%26)
+		if _, err := r.Intern(code); err != nil {
+			t.Fatalf("%q: %v", code, err)
+		}
+	}
+
+	_, err := r.Intern("ZZ")
+	if !errors.Is(err, ErrFull) {
+		t.Fatalf("expected ErrFull, got %v", err)
+	}
+}
+
+func TestCountsMap(t *testing.T) {
+	r := NewRegistry()
+	var c Counts
+	c[r.MustIntern("AD")] += 2
+	c[r.MustIntern("BB")]++
+	r.MustIntern("CA")
+
+	m := c.Map(r)
+	expected := map[string]int{"AD": 2, "BB": 1}
+	if len(m) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, m)
+	}
+	for k, v := range expected {
+		if m[k] != v {
+			t.Fatalf("expected %v, got %v", expected, m)
+		}
+	}
+}
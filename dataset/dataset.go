@@ -0,0 +1,325 @@
+// Package dataset generates synthetic users for the layout benchmarks.
+//
+// Generate returns layout neutral records, each layout package converts them
+// to its own User type and fills icons with Config.FillIcon or Config.NewIcon.
+package dataset
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"users/icon"
+)
+
+// IconSize is the size of a 128x128 icon in bytes.
+const IconSize = icon.Size
+
+// Distribution is how countries are assigned to users.
+type Distribution int
+
+const (
+	// Cyclic assigns Countries[i%len(Countries)] to user i.
+	Cyclic Distribution = iota
+	// Uniform picks a random country for every user.
+	Uniform
+	// Zipf picks random countries where the first ones are much more common.
+	Zipf
+)
+
+// Order is the order of the generated users.
+type Order int
+
+const (
+	// Sequential keeps users in generation order.
+	Sequential Order = iota
+	// Sorted groups users by country, in Countries order.
+	Sorted
+	// Shuffled randomizes the order of users.
+	Shuffled
+)
+
+// IconFill is how icons are filled.
+type IconFill int
+
+const (
+	// IconNone leaves icons empty (nil in the slice layouts).
+	IconNone IconFill = iota
+	// IconZero allocates icons and leaves them zero.
+	IconZero
+	// IconPattern fills icons with a gradient that depends on the user.
+	IconPattern
+	// IconRandom fills icons with random bytes.
+	IconRandom
+	// IconFiles copies Icons[ID%len(Icons)], see LoadIcons.
+	IconFiles
+)
+
+// DefaultCountries are the countries of the original benchmark fixture.
+var DefaultCountries = []string{
+	"AD",
+	"BB",
+	"CA",
+	"DK",
+}
+
+// Config configures Generate.
+type Config struct {
+	Size         int
+	Countries    []string
+	Distribution Distribution
+	ZipfS        float64 // Zipf exponent, must be > 1
+	ActiveRatio  float64 // fraction of active users, the inactive ones are spread evenly
+	Order        Order
+	Seed         int64
+	Icon         IconFill
+	Icons        [][]byte // IconSize icons for IconFiles
+}
+
+// Default returns the configuration of the original fixture: 10,000 users,
+// four countries in turn and every fifth user inactive.
+func Default() Config {
+	return Config{
+		Size:        10_000,
+		Countries:   DefaultCountries,
+		ZipfS:       1.1,
+		ActiveRatio: 0.8,
+		Seed:        1,
+		Icon:        IconZero,
+	}
+}
+
+// User is a generated user.
+type User struct {
+	ID      int // index before ordering, used to fill icons
+	Login   string
+	Active  bool
+	Country string
+}
+
+// Validate returns an error if c is not a valid configuration.
+func (c Config) Validate() error {
+	var errs []error
+	if c.Size < 0 {
+		errs = append(errs, fmt.Errorf("negative size: %d", c.Size))
+	}
+	if len(c.Countries) == 0 {
+		errs = append(errs, errors.New("no countries"))
+	}
+	if c.ActiveRatio < 0 || c.ActiveRatio > 1 {
+		errs = append(errs, fmt.Errorf("active ratio %v not in [0, 1]", c.ActiveRatio))
+	}
+	if c.Distribution == Zipf && c.ZipfS <= 1 {
+		errs = append(errs, fmt.Errorf("Zipf exponent %v must be > 1", c.ZipfS))
+	}
+	if c.Icon == IconFiles {
+		if len(c.Icons) == 0 {
+			errs = append(errs, errors.New("no icons"))
+		}
+		for i, icon := range c.Icons {
+			if len(icon) != IconSize {
+				errs = append(errs, fmt.Errorf("icon %d: %d bytes, expected %d", i, len(icon), IconSize))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Generate generates c.Size users.
+func Generate(c Config) ([]User, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	rng := rand.New(rand.NewSource(c.Seed))
+	country := c.countryFunc(rng)
+	inactive := c.Size - int(math.Round(float64(c.Size)*c.ActiveRatio))
+
+	users := make([]User, c.Size)
+	for i := range users {
+		users[i] = User{
+			ID:      i,
+			Login:   fmt.Sprintf("user%d", i),
+			Active:  !spread(i, inactive, c.Size),
+			Country: c.Countries[country(i)],
+		}
+	}
+
+	switch c.Order {
+	case Sorted:
+		index := make(map[string]int, len(c.Countries))
+		for i, name := range c.Countries {
+			if _, ok := index[name]; !ok {
+				index[name] = i
+			}
+		}
+		sort.SliceStable(users, func(i, j int) bool {
+			return index[users[i].Country] < index[users[j].Country]
+		})
+	case Shuffled:
+		rng.Shuffle(len(users), func(i, j int) {
+			users[i], users[j] = users[j], users[i]
+		})
+	}
+
+	return users, nil
+}
+
+// MustGenerate is like Generate but panics on error, use it in benchmark setup.
+func MustGenerate(c Config) []User {
+	users, err := Generate(c)
+	if err != nil {
+		panic(err)
+	}
+	return users
+}
+
+// countryFunc returns a function from user index to country index.
+func (c Config) countryFunc(rng *rand.Rand) func(i int) int {
+	n := len(c.Countries)
+	switch c.Distribution {
+	case Uniform:
+		return func(int) int { return rng.Intn(n) }
+	case Zipf:
+		z := rand.NewZipf(rng, c.ZipfS, 1, uint64(n-1))
+		return func(int) int { return int(z.Uint64()) }
+	default:
+		return func(i int) int { return i % n }
+	}
+}
+
+// spread reports if i is one of k items spread evenly over n.
+// With k = n/5, it's true for every fifth item starting at 0.
+func spread(i, k, n int) bool {
+	return ceilDiv((i+1)*k, n) > ceilDiv(i*k, n)
+}
+
+func ceilDiv(a, b int) int {
+	return (a + b - 1) / b
+}
+
+// FillIcon fills dst, the icon of u, according to c.Icon.
+// IconNone and IconZero leave dst as is.
+func (c Config) FillIcon(u User, dst []byte) {
+	switch c.Icon {
+	case IconPattern:
+		for i := range dst {
+			x, y := i%128, i/128
+			dst[i] = byte(x + y + u.ID)
+		}
+	case IconRandom:
+		state := uint64(c.Seed)<<32 ^ uint64(u.ID)
+		for i := 0; i < len(dst); i += 8 {
+			v := splitmix64(&state)
+			for j := 0; j < 8 && i+j < len(dst); j++ {
+				dst[i+j] = byte(v >> (8 * j))
+			}
+		}
+	case IconFiles:
+		copy(dst, c.Icons[u.ID%len(c.Icons)])
+	}
+}
+
+// LoadIcons returns the icons decoded from the PNG files in dir, in file name
+// order. Images are converted to grayscale and resized to 128x128.
+func LoadIcons(dir string) ([][]byte, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var icons [][]byte
+	for _, e := range entries { // sorted by name
+		if e.IsDir() || !strings.EqualFold(filepath.Ext(e.Name()), ".png") {
+			continue
+		}
+		pix, err := icon.Load(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		icons = append(icons, pix)
+	}
+
+	if len(icons) == 0 {
+		return nil, fmt.Errorf("%s: no PNG icons", dir)
+	}
+	return icons, nil
+}
+
+// NewIcon returns a new icon for u, or nil if c.Icon is IconNone.
+func (c Config) NewIcon(u User) []byte {
+	if c.Icon == IconNone {
+		return nil
+	}
+
+	icon := make([]byte, IconSize)
+	c.FillIcon(u, icon)
+	return icon
+}
+
+// splitmix64 is a small, fast PRNG, see https://prng.di.unimi.it/splitmix64.c
+func splitmix64(state *uint64) uint64 {
+	*state += 0x9e3779b97f4a7c15
+	z := *state
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// Codes returns n distinct two letter country codes: AA, AB ... ZZ.
+// n must be at most 26*26.
+func Codes(n int) []string {
+	codes := make([]string, n)
+	for i := range codes {
+		codes[i] = string([]byte{byte('A' + i/26), byte('A' + i%26)})
+	}
+	return codes
+}
+
+// Sweep limits, see SweepSizes.
+const (
+	MinSweepUsers = 256
+	MaxSweepUsers = 4 << 20
+	MaxSweepBytes = 1 << 30
+)
+
+// SweepSizes returns user counts growing 4x from MinSweepUsers to
+// MaxSweepUsers, stopping before the users take more than MaxSweepBytes at
+// bytesPerUser each.
+func SweepSizes(bytesPerUser int64) []int {
+	var sizes []int
+	for n := MinSweepUsers; n <= MaxSweepUsers; n *= 4 {
+		if int64(n)*bytesPerUser > MaxSweepBytes {
+			break
+		}
+		sizes = append(sizes, n)
+	}
+	return sizes
+}
+
+// Permutation returns a random permutation of [0, n), use it as a visit order.
+func Permutation(n int, seed int64) []int {
+	return rand.New(rand.NewSource(seed)).Perm(n)
+}
+
+// Strided returns a visit order of [0, n) that jumps stride indices at a
+// time: 0, stride, 2*stride ... then 1, 1+stride ... Every index appears once.
+func Strided(n, stride int) []int {
+	if stride < 1 {
+		stride = 1
+	}
+
+	order := make([]int, 0, n)
+	for start := 0; start < stride && start < n; start++ {
+		for i := start; i < n; i += stride {
+			order = append(order, i)
+		}
+	}
+	return order
+}
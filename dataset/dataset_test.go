@@ -0,0 +1,276 @@
+package dataset
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDefault(t *testing.T) {
+	cfg := Default()
+	users, err := Generate(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(users) != cfg.Size {
+		t.Fatalf("expected %d users, got %d", cfg.Size, len(users))
+	}
+
+	// Same as the original fixture.
+	for i, u := range users {
+		if u.Active != (i%5 > 0) || u.Country != DefaultCountries[i%len(DefaultCountries)] || u.ID != i {
+			t.Fatalf("%d: bad user %+v", i, u)
+		}
+	}
+}
+
+func countActive(users []User) int {
+	n := 0
+	for _, u := range users {
+		if u.Active {
+			n++
+		}
+	}
+	return n
+}
+
+func TestActiveRatio(t *testing.T) {
+	cases := []struct {
+		size     int
+		ratio    float64
+		expected int
+	}{
+		{1000, 0, 0},
+		{1000, 1, 1000},
+		{1000, 0.25, 250},
+		{7, 0.5, 4},
+	}
+	for _, tc := range cases {
+		cfg := Default()
+		cfg.Size, cfg.ActiveRatio = tc.size, tc.ratio
+		if n := countActive(MustGenerate(cfg)); n != tc.expected {
+			t.Errorf("%d users, ratio %v: expected %d active, got %d", tc.size, tc.ratio, tc.expected, n)
+		}
+	}
+}
+
+func TestOrder(t *testing.T) {
+	cfg := Default()
+	cfg.Size = 100
+	cfg.Distribution = Uniform
+
+	cfg.Order = Sorted
+	users := MustGenerate(cfg)
+	last := 0
+	for _, u := range users {
+		i := indexOf(DefaultCountries, u.Country)
+		if i < last {
+			t.Fatalf("not sorted: %v", users)
+		}
+		last = i
+	}
+
+	cfg.Order = Shuffled
+	users = MustGenerate(cfg)
+	seen := make(map[int]bool)
+	moved := false
+	for i, u := range users {
+		seen[u.ID] = true
+		if u.ID != i {
+			moved = true
+		}
+	}
+	if len(seen) != cfg.Size || !moved {
+		t.Fatal("bad shuffle")
+	}
+
+	if again := MustGenerate(cfg); !reflect.DeepEqual(users, again) {
+		t.Fatal("same seed, different users")
+	}
+}
+
+func indexOf(values []string, s string) int {
+	for i, v := range values {
+		if v == s {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestZipf(t *testing.T) {
+	cfg := Default()
+	cfg.Countries = Codes(50)
+	cfg.Distribution = Zipf
+
+	counts := make(map[string]int)
+	for _, u := range MustGenerate(cfg) {
+		counts[u.Country]++
+	}
+
+	if counts["AA"] <= counts["AB"] || counts["AB"] <= counts["BX"] {
+		t.Fatalf("not skewed: AA=%d AB=%d BX=%d", counts["AA"], counts["AB"], counts["BX"])
+	}
+}
+
+func TestIcons(t *testing.T) {
+	cfg := Default()
+	cfg.Size = 2
+	users := MustGenerate(cfg)
+
+	if icon := cfg.NewIcon(users[0]); len(icon) != IconSize || !bytes.Equal(icon, make([]byte, IconSize)) {
+		t.Fatal("IconZero: expected zero icon")
+	}
+
+	cfg.Icon = IconNone
+	if icon := cfg.NewIcon(users[0]); icon != nil {
+		t.Fatal("IconNone: expected nil")
+	}
+
+	for _, fill := range []IconFill{IconPattern, IconRandom} {
+		cfg.Icon = fill
+		a, b := cfg.NewIcon(users[0]), cfg.NewIcon(users[1])
+		if bytes.Equal(a, b) {
+			t.Fatalf("%d: same icon for different users", fill)
+		}
+		if !bytes.Equal(a, cfg.NewIcon(users[0])) {
+			t.Fatalf("%d: icon not deterministic", fill)
+		}
+	}
+}
+
+func TestLoadIcons(t *testing.T) {
+	dir := t.TempDir()
+	for i, name := range []string{"b.png", "a.PNG"} {
+		img := image.NewGray(image.Rect(0, 0, 16, 16))
+		for j := range img.Pix {
+			img.Pix[j] = byte(i + 1)
+		}
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), buf.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "README"), []byte("not an icon"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	icons, err := LoadIcons(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(icons) != 2 {
+		t.Fatalf("expected 2 icons, got %d", len(icons))
+	}
+	// a.PNG (2) before b.png (1)
+	for i, v := range []byte{2, 1} {
+		if !bytes.Equal(icons[i], bytes.Repeat([]byte{v}, IconSize)) {
+			t.Fatalf("icon %d: expected all %d", i, v)
+		}
+	}
+
+	cfg := Default()
+	cfg.Size = 3
+	cfg.Icon, cfg.Icons = IconFiles, icons
+	users := MustGenerate(cfg)
+	for _, u := range users {
+		if !bytes.Equal(cfg.NewIcon(u), icons[u.ID%len(icons)]) {
+			t.Fatalf("user %d: wrong icon", u.ID)
+		}
+	}
+
+	if _, err := LoadIcons(t.TempDir()); err == nil {
+		t.Fatal("expected error for directory without icons")
+	}
+	if err := os.WriteFile(filepath.Join(dir, "c.png"), []byte("not a png"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadIcons(dir); err == nil {
+		t.Fatal("expected error for bad PNG")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cases := []func(*Config){
+		func(c *Config) { c.Size = -1 },
+		func(c *Config) { c.Countries = nil },
+		func(c *Config) { c.ActiveRatio = 1.5 },
+		func(c *Config) { c.Distribution, c.ZipfS = Zipf, 1 },
+		func(c *Config) { c.Icon = IconFiles },
+		func(c *Config) { c.Icon, c.Icons = IconFiles, [][]byte{{1, 2, 3}} },
+	}
+	for i, modify := range cases {
+		cfg := Default()
+		modify(&cfg)
+		if _, err := Generate(cfg); err == nil {
+			t.Errorf("case %d: no error", i)
+		}
+	}
+}
+
+func TestCodes(t *testing.T) {
+	codes := Codes(28)
+	if codes[0] != "AA" || codes[25] != "AZ" || codes[27] != "BB" {
+		t.Fatalf("bad codes: %v", codes)
+	}
+}
+
+func TestSweepSizes(t *testing.T) {
+	sizes := SweepSizes(64)
+	expected := []int{256, 1 << 10, 4 << 10, 16 << 10, 64 << 10, 256 << 10, 1 << 20, 4 << 20}
+	if !reflect.DeepEqual(sizes, expected) {
+		t.Fatalf("expected %v, got %v", expected, sizes)
+	}
+
+	sizes = SweepSizes(16424)
+	if last := sizes[len(sizes)-1]; last != 16<<10 {
+		t.Fatalf("16K users: expected last size %d, got %d", 16<<10, last)
+	}
+}
+
+func isPermutation(order []int, n int) bool {
+	if len(order) != n {
+		return false
+	}
+
+	seen := make([]bool, n)
+	for _, i := range order {
+		if i < 0 || i >= n || seen[i] {
+			return false
+		}
+		seen[i] = true
+	}
+	return true
+}
+
+func TestPermutation(t *testing.T) {
+	order := Permutation(100, 7)
+	if !isPermutation(order, 100) {
+		t.Fatalf("not a permutation: %v", order)
+	}
+	if !reflect.DeepEqual(order, Permutation(100, 7)) {
+		t.Fatal("same seed, different order")
+	}
+}
+
+func TestStrided(t *testing.T) {
+	order := Strided(7, 3)
+	expected := []int{0, 3, 6, 1, 4, 2, 5}
+	if !reflect.DeepEqual(order, expected) {
+		t.Fatalf("expected %v, got %v", expected, order)
+	}
+
+	for _, stride := range []int{0, 1, 5, 200} {
+		if order := Strided(100, stride); !isPermutation(order, 100) {
+			t.Fatalf("stride %d: not a permutation: %v", stride, order)
+		}
+	}
+}
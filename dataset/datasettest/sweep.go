@@ -0,0 +1,37 @@
+// Package datasettest runs layout benchmarks over dataset generated users.
+package datasettest
+
+import (
+	"fmt"
+	"testing"
+
+	"users/dataset"
+)
+
+// Sweep runs a "users=N" sub-benchmark of b for every size of
+// dataset.SweepSizes(footprint), where footprint is the bytes a user takes
+// in memory. setup gets the Default configuration with N users and no icons,
+// and returns the CountryCount call to time.
+//
+// Sweep reports ns/user and B/user metrics. B/user is touched, the bytes of
+// the fields CountryCount reads per user, so working sets of all layouts are
+// comparable.
+func Sweep(b *testing.B, footprint, touched int64, setup func(cfg dataset.Config) func() map[string]int) {
+	for _, n := range dataset.SweepSizes(footprint) {
+		b.Run(fmt.Sprintf("users=%d", n), func(b *testing.B) {
+			cfg := dataset.Default()
+			cfg.Size = n
+			cfg.Icon = dataset.IconNone // not read by CountryCount
+			count := setup(cfg)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				m := count()
+				if m == nil {
+					b.Fatal(m)
+				}
+			}
+			b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N)/float64(n), "ns/user")
+			b.ReportMetric(float64(touched), "B/user")
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package falseshare
+
+import (
+	"fmt"
+	"runtime"
+	"testing"
+	"unsafe"
+)
+
+func TestPaddedSize(t *testing.T) {
+	if size := unsafe.Sizeof(paddedCounter{}); size != CacheLineSize {
+		t.Fatalf("expected %d, got %d", CacheLineSize, size)
+	}
+}
+
+func TestRun(t *testing.T) {
+	const workers, n = 4, 1000
+	for _, c := range []Counters{NewPacked(workers), NewPadded(workers)} {
+		Run(c, workers, n)
+		for i := 0; i < workers; i++ {
+			if v := c.Value(i); v != n {
+				t.Fatalf("%T[%d]: expected %d, got %d", c, i, n, v)
+			}
+		}
+	}
+}
+
+func workerCounts() []int {
+	counts := []int{1, 2, 4, 8}
+	if n := runtime.GOMAXPROCS(0); n > counts[len(counts)-1] {
+		counts = append(counts, n)
+	}
+	return counts
+}
+
+func benchmarkCounters(b *testing.B, newCounters func(n int) Counters) {
+	for _, workers := range workerCounts() {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			c := newCounters(workers)
+			b.ResetTimer()
+			Run(c, workers, b.N)
+		})
+	}
+}
+
+func BenchmarkPacked(b *testing.B) {
+	benchmarkCounters(b, func(n int) Counters { return NewPacked(n) })
+}
+
+func BenchmarkPadded(b *testing.B) {
+	benchmarkCounters(b, func(n int) Counters { return NewPadded(n) })
+}
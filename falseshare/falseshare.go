@@ -0,0 +1,70 @@
+// Package falseshare shows the cost of false sharing: counters that are
+// written by different cores but live on the same cache line.
+package falseshare
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// CacheLineSize is the coherency size from cache.txt.
+const CacheLineSize = 64
+
+// Counters is a set of counters, one per worker.
+type Counters interface {
+	Inc(i int)
+	Value(i int) uint64
+}
+
+// Packed counters are adjacent in memory, 8 of them share a cache line.
+type Packed []uint64
+
+// NewPacked returns n packed counters.
+func NewPacked(n int) Packed {
+	return make(Packed, n)
+}
+
+func (p Packed) Inc(i int) {
+	atomic.AddUint64(&p[i], 1)
+}
+
+func (p Packed) Value(i int) uint64 {
+	return atomic.LoadUint64(&p[i])
+}
+
+type paddedCounter struct {
+	n uint64
+	_ [CacheLineSize - 8]byte
+}
+
+// Padded counters each have a cache line of their own.
+type Padded []paddedCounter
+
+// NewPadded returns n padded counters.
+func NewPadded(n int) Padded {
+	return make(Padded, n)
+}
+
+func (p Padded) Inc(i int) {
+	atomic.AddUint64(&p[i].n, 1)
+}
+
+func (p Padded) Value(i int) uint64 {
+	return atomic.LoadUint64(&p[i].n)
+}
+
+// Run starts workers goroutines, worker i increments counter i n times.
+// It returns once all workers are done.
+func Run(c Counters, workers, n int) {
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < n; i++ {
+				c.Inc(w)
+			}
+		}(w)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,34 @@
+package users
+
+import (
+	"testing"
+	"unsafe"
+
+	"users/dataset"
+	"users/dataset/datasettest"
+)
+
+var users *Users
+
+func init() {
+	users = MustGenerate(dataset.Default())
+}
+
+func BenchmarkCountryCount(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		m := users.CountryCount()
+		if m == nil {
+			b.Fatal(m)
+		}
+	}
+}
+
+func BenchmarkCountryCountSweep(b *testing.B) {
+	// CountryCount reads only hot records.
+	footprint := int64(unsafe.Sizeof(Hot{}) + unsafe.Sizeof(Cold{}))
+	touched := int64(unsafe.Sizeof(Hot{}.Active) + unsafe.Sizeof(Hot{}.Country))
+	datasettest.Sweep(b, footprint, touched, func(cfg dataset.Config) func() map[string]int {
+		users := MustGenerate(cfg)
+		return users.CountryCount
+	})
+}
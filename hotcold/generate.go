@@ -0,0 +1,23 @@
+package users
+
+import "users/dataset"
+
+// MustGenerate returns a store of users generated from cfg.
+// It panics if cfg is invalid or has more than MaxCountries countries, use it
+// in benchmark setup.
+func MustGenerate(cfg dataset.Config) *Users {
+	records := dataset.MustGenerate(cfg)
+	users := NewUsers(len(records))
+	for _, r := range records {
+		_, err := users.Append(User{
+			Login:   r.Login,
+			Active:  r.Active,
+			Country: r.Country,
+			Icon:    cfg.NewIcon(r),
+		})
+		if err != nil {
+			panic(err)
+		}
+	}
+	return users
+}
@@ -0,0 +1,138 @@
+package users
+
+import (
+	"errors"
+	"fmt"
+)
+
+// MaxCountries is the number of distinct countries a store can hold.
+const MaxCountries = 1 << 16
+
+// ErrFull is returned when adding a user with a new country to a store
+// holding MaxCountries countries.
+var ErrFull = errors.New("too many countries")
+
+type Image []byte
+
+type User struct {
+	Login   string
+	Active  bool
+	Icon    Image
+	Country string
+}
+
+// Hot is the part of a user read by CountryCount.
+// Country is an index into the store's country list.
+type Hot struct {
+	Active  bool
+	Country uint16
+}
+
+// Cold is the part of a user that is rarely read.
+type Cold struct {
+	Login string
+	Icon  Image
+}
+
+// Users stores hot and cold records in separate slices, row i of one matches
+// row i of the other.
+type Users struct {
+	hot       []Hot
+	cold      []Cold
+	countries []string          // id -> country
+	ids       map[string]uint16 // country -> id
+}
+
+// NewUsers returns an empty store with room for size users.
+func NewUsers(size int) *Users {
+	return &Users{
+		hot:  make([]Hot, 0, size),
+		cold: make([]Cold, 0, size),
+		ids:  make(map[string]uint16),
+	}
+}
+
+// FromUsers returns a store holding users.
+func FromUsers(users []User) (*Users, error) {
+	s := NewUsers(len(users))
+	for _, u := range users {
+		if _, err := s.Append(u); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// Len returns the number of users in the store.
+func (s *Users) Len() int {
+	return len(s.hot)
+}
+
+// Append adds u at the end of the store and returns its index.
+// It returns ErrFull if u has a new country and the store is full.
+func (s *Users) Append(u User) (int, error) {
+	id, err := s.countryID(u.Country)
+	if err != nil {
+		return 0, err
+	}
+	s.hot = append(s.hot, Hot{Active: u.Active, Country: id})
+	s.cold = append(s.cold, Cold{Login: u.Login, Icon: u.Icon})
+	return len(s.hot) - 1, nil
+}
+
+// Hot returns the hot record at index i.
+func (s *Users) Hot(i int) Hot {
+	return s.hot[i]
+}
+
+// Cold returns the cold record at index i.
+func (s *Users) Cold(i int) Cold {
+	return s.cold[i]
+}
+
+// User reassembles the full user at index i.
+func (s *Users) User(i int) User {
+	h, c := s.hot[i], s.cold[i]
+	return User{
+		Login:   c.Login,
+		Active:  h.Active,
+		Icon:    c.Icon,
+		Country: s.countries[h.Country],
+	}
+}
+
+// CountryCount returns map of country to number of active users.
+// Only hot records are read.
+func (s *Users) CountryCount() map[string]int {
+	counts := make([]int, len(s.countries)) // country id -> count
+	for _, h := range s.hot {
+		if !h.Active {
+			continue
+		}
+		counts[h.Country]++
+	}
+
+	m := make(map[string]int)
+	for id, n := range counts {
+		if n > 0 {
+			m[s.countries[id]] = n
+		}
+	}
+	return m
+}
+
+func (s *Users) countryID(country string) (uint16, error) {
+	id, ok := s.ids[country]
+	if ok {
+		return id, nil
+	}
+
+	if len(s.countries) == MaxCountries {
+		return 0, fmt.Errorf("%w: can't add %q", ErrFull, country)
+	}
+	id = uint16(len(s.countries))
+	s.countries = append(s.countries, country)
+	s.ids[country] = id
+	return id, nil
+}
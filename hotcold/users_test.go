@@ -0,0 +1,73 @@
+package users
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// countryCount is the plain []User count the layouts are checked against.
+func countryCount(users []User) map[string]int {
+	counts := make(map[string]int) // country -> count
+	for _, u := range users {
+		if u.Active {
+			counts[u.Country]++
+		}
+	}
+	return counts
+}
+
+func TestFromUsers(t *testing.T) {
+	var users []User
+	for i := 0; i < 100; i++ {
+		users = append(users, User{
+			Login:   fmt.Sprintf("user%d", i),
+			Active:  i%3 != 0,
+			Icon:    Image{byte(i)},
+			Country: []string{"AD", "BB", "CA", "DK", "EG"}[i%5],
+		})
+	}
+
+	s, err := FromUsers(users)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Len() != len(users) {
+		t.Fatalf("expected %d users, got %d", len(users), s.Len())
+	}
+	for i, u := range users {
+		if out := s.User(i); !reflect.DeepEqual(out, u) {
+			t.Fatalf("%d: expected %+v, got %+v", i, u, out)
+		}
+	}
+
+	expected := countryCount(users)
+	if counts := s.CountryCount(); !reflect.DeepEqual(counts, expected) {
+		t.Fatalf("expected %v, got %v", expected, counts)
+	}
+}
+
+func TestAppendFull(t *testing.T) {
+	s := NewUsers(0)
+	for i := 0; i < MaxCountries; i++ {
+		if _, err := s.Append(User{Country: fmt.Sprint(i)}); err != nil {
+			t.Fatalf("%d: %v", i, err)
+		}
+	}
+
+	if _, err := s.Append(User{Country: "new"}); !errors.Is(err, ErrFull) {
+		t.Fatalf("expected %v, got %v", ErrFull, err)
+	}
+	// Known countries can still be added.
+	i, err := s.Append(User{Active: true, Country: "0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u := s.User(i); u.Country != "0" {
+		t.Fatalf("expected country 0, got %q", u.Country)
+	}
+	if s.Len() != MaxCountries+1 {
+		t.Fatalf("expected %d users, got %d", MaxCountries+1, s.Len())
+	}
+}
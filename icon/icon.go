@@ -0,0 +1,125 @@
+// Package icon converts user icons to and from images.
+//
+// An icon is 128x128 8 bit grayscale pixels in row major order, the layout
+// of Image in every layout package.
+package icon
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+)
+
+const (
+	// Side is the width and height of an icon in pixels.
+	Side = 128
+	// Size is the size of an icon in bytes.
+	Size = Side * Side
+)
+
+// ErrSize is returned for icons that are not Size bytes.
+var ErrSize = errors.New("bad icon size")
+
+func checkSize(pix []byte) error {
+	if len(pix) != Size {
+		return fmt.Errorf("%w: %d bytes, expected %d", ErrSize, len(pix), Size)
+	}
+	return nil
+}
+
+// ToGray returns a grayscale image with a copy of the pixels of icon.
+func ToGray(icon []byte) (*image.Gray, error) {
+	if err := checkSize(icon); err != nil {
+		return nil, err
+	}
+
+	img := image.NewGray(image.Rect(0, 0, Side, Side))
+	copy(img.Pix, icon)
+	return img, nil
+}
+
+// FromImage converts img to grayscale and resizes it to Side x Side into dst.
+// Each icon pixel is the average of the image pixels it covers, or the
+// nearest image pixel when enlarging.
+func FromImage(dst []byte, img image.Image) error {
+	if err := checkSize(dst); err != nil {
+		return err
+	}
+
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w == 0 || h == 0 {
+		return fmt.Errorf("empty image: %v", b)
+	}
+
+	if g, ok := img.(*image.Gray); ok && w == Side && h == Side {
+		for y := 0; y < Side; y++ {
+			copy(dst[y*Side:(y+1)*Side], g.Pix[g.PixOffset(b.Min.X, b.Min.Y+y):])
+		}
+		return nil
+	}
+
+	for y := 0; y < Side; y++ {
+		y0, y1 := span(y, h)
+		for x := 0; x < Side; x++ {
+			x0, x1 := span(x, w)
+			var sum, n int
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					c := color.GrayModel.Convert(img.At(b.Min.X+sx, b.Min.Y+sy)).(color.Gray)
+					sum += int(c.Y)
+					n++
+				}
+			}
+			dst[y*Side+x] = byte((sum + n/2) / n)
+		}
+	}
+
+	return nil
+}
+
+// span returns the range of image pixels, out of n, covered by icon pixel i.
+func span(i, n int) (int, int) {
+	start, end := i*n/Side, (i+1)*n/Side
+	if end == start {
+		end = start + 1
+	}
+	return start, end
+}
+
+// Decode decodes a PNG from r into dst, resizing it to Side x Side.
+func Decode(dst []byte, r io.Reader) error {
+	img, err := png.Decode(r)
+	if err != nil {
+		return err
+	}
+	return FromImage(dst, img)
+}
+
+// Encode writes icon to w as a grayscale PNG.
+func Encode(w io.Writer, icon []byte) error {
+	img, err := ToGray(icon)
+	if err != nil {
+		return err
+	}
+	return png.Encode(w, img)
+}
+
+// Load returns the icon decoded from the PNG file at path.
+func Load(path string) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	icon := make([]byte, Size)
+	if err := Decode(icon, file); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return icon, nil
+}
@@ -0,0 +1,133 @@
+package icon
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"testing"
+)
+
+// gradient returns an icon with a gradient that depends on seed.
+func gradient(seed int) []byte {
+	icon := make([]byte, Size)
+	for i := range icon {
+		x, y := i%Side, i/Side
+		icon[i] = byte(x + 2*y + seed)
+	}
+	return icon
+}
+
+func TestRoundTrip(t *testing.T) {
+	icon := gradient(3)
+
+	var buf bytes.Buffer
+	if err := Encode(&buf, icon); err != nil {
+		t.Fatal(err)
+	}
+
+	out := make([]byte, Size)
+	if err := Decode(out, &buf); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(icon, out) {
+		t.Fatal("round trip mismatch")
+	}
+
+	img, err := ToGray(icon)
+	if err != nil {
+		t.Fatal(err)
+	}
+	icon[0]++
+	if img.GrayAt(0, 0).Y == icon[0] {
+		t.Fatal("image shares icon pixels")
+	}
+}
+
+func TestFromImageResize(t *testing.T) {
+	// 256x256 RGBA, 2x2 blocks of black, white, black, white ...
+	big := image.NewRGBA(image.Rect(10, 10, 10+2*Side, 10+2*Side))
+	for y := 0; y < 2*Side; y++ {
+		for x := 0; x < 2*Side; x++ {
+			c := color.RGBA{A: 0xFF}
+			if x%2 == 1 {
+				c = color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}
+			}
+			big.Set(10+x, 10+y, c)
+		}
+	}
+
+	icon := make([]byte, Size)
+	if err := FromImage(icon, big); err != nil {
+		t.Fatal(err)
+	}
+	for i, v := range icon {
+		if v != 0x80 {
+			t.Fatalf("%d: expected average 0x80, got %#x", i, v)
+		}
+	}
+
+	// 2x2 gray, enlarged into 64x64 blocks
+	small := image.NewGray(image.Rect(0, 0, 2, 2))
+	small.Pix = []byte{1, 2, 3, 4}
+	if err := FromImage(icon, small); err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		x, y int
+		v    byte
+	}{
+		{0, 0, 1},
+		{Side - 1, 0, 2},
+		{0, Side - 1, 3},
+		{Side/2 - 1, Side / 2, 3},
+		{Side / 2, Side / 2, 4},
+	}
+	for _, tc := range cases {
+		if v := icon[tc.y*Side+tc.x]; v != tc.v {
+			t.Fatalf("(%d, %d): expected %d, got %d", tc.x, tc.y, tc.v, v)
+		}
+	}
+}
+
+func TestFromImageSubImage(t *testing.T) {
+	big := image.NewGray(image.Rect(0, 0, Side+5, Side+5))
+	for i := range big.Pix {
+		big.Pix[i] = byte(i)
+	}
+	sub := big.SubImage(image.Rect(5, 5, Side+5, Side+5))
+
+	icon := make([]byte, Size)
+	if err := FromImage(icon, sub); err != nil {
+		t.Fatal(err)
+	}
+	for y := 0; y < Side; y++ {
+		for x := 0; x < Side; x++ {
+			if v, expected := icon[y*Side+x], big.GrayAt(x+5, y+5).Y; v != expected {
+				t.Fatalf("(%d, %d): expected %d, got %d", x, y, expected, v)
+			}
+		}
+	}
+}
+
+func TestSize(t *testing.T) {
+	for _, size := range []int{0, Size - 1, Size + 1} {
+		pix := make([]byte, size)
+		if _, err := ToGray(pix); !errors.Is(err, ErrSize) {
+			t.Fatalf("%d: expected %v, got %v", size, ErrSize, err)
+		}
+		if err := Encode(&bytes.Buffer{}, pix); !errors.Is(err, ErrSize) {
+			t.Fatalf("%d: expected %v, got %v", size, ErrSize, err)
+		}
+		if err := FromImage(pix, image.NewGray(image.Rect(0, 0, 1, 1))); !errors.Is(err, ErrSize) {
+			t.Fatalf("%d: expected %v, got %v", size, ErrSize, err)
+		}
+	}
+
+	if err := FromImage(make([]byte, Size), image.NewGray(image.Rect(0, 0, 0, 0))); err == nil {
+		t.Fatal("expected error for empty image")
+	}
+	if err := Decode(make([]byte, Size), bytes.NewReader([]byte("not a png"))); err == nil {
+		t.Fatal("expected error for bad PNG")
+	}
+}
@@ -0,0 +1,38 @@
+package users
+
+import (
+	"testing"
+	"unsafe"
+
+	"users/country"
+	"users/dataset"
+	"users/dataset/datasettest"
+)
+
+var (
+	users    []User
+	registry = country.NewRegistry()
+)
+
+func init() {
+	users = MustGenerate(registry, dataset.Default())
+}
+
+func BenchmarkCountryCount(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		m := CountryCount(registry, users)
+		if m == nil {
+			b.Fatal(m)
+		}
+	}
+}
+
+func BenchmarkCountryCountSweep(b *testing.B) {
+	// CountryCount reads Active and Country.
+	touched := int64(unsafe.Sizeof(User{}.Active) + unsafe.Sizeof(User{}.Country))
+	datasettest.Sweep(b, int64(unsafe.Sizeof(User{})), touched, func(cfg dataset.Config) func() map[string]int {
+		reg := country.NewRegistry()
+		users := MustGenerate(reg, cfg)
+		return func() map[string]int { return CountryCount(reg, users) }
+	})
+}
@@ -0,0 +1,20 @@
+package users
+
+import (
+	"users/country"
+	"users/dataset"
+)
+
+// MustGenerate returns users generated from cfg, countries are interned in
+// reg. It panics if cfg is invalid or reg is full, use it in benchmark setup.
+func MustGenerate(reg *country.Registry, cfg dataset.Config) []User {
+	records := dataset.MustGenerate(cfg)
+	users := make([]User, len(records))
+	for i, r := range records {
+		users[i].Login = r.Login
+		users[i].Active = r.Active
+		users[i].Country = reg.MustIntern(r.Country)
+		users[i].Icon = cfg.NewIcon(r)
+	}
+	return users
+}
@@ -0,0 +1,26 @@
+package users
+
+import "users/country"
+
+type Image []byte
+
+type User struct {
+	Login   string
+	Active  bool
+	Icon    Image
+	Country country.ID
+}
+
+// CountryCount returns map of country to number of active users.
+// Country IDs are resolved using reg.
+func CountryCount(reg *country.Registry, users []User) map[string]int {
+	var counts country.Counts // country id -> count
+	for _, u := range users {
+		if !u.Active {
+			continue
+		}
+		counts[u.Country]++
+	}
+
+	return counts.Map(reg)
+}
@@ -0,0 +1,78 @@
+package users
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"users/country"
+	"users/dataset"
+)
+
+// countryCount is the plain string keyed count the layout is checked against.
+func countryCount(codes []string, active []bool) map[string]int {
+	counts := make(map[string]int) // country -> count
+	for i, code := range codes {
+		if active[i] {
+			counts[code]++
+		}
+	}
+	return counts
+}
+
+func TestCountryCount(t *testing.T) {
+	reg := country.NewRegistry()
+	codes := []string{"AD", "", "BB", "CA", "", "DK", "EG"}
+	var (
+		users  []User
+		active []bool
+		all    []string
+	)
+	for i := 0; i < 100; i++ {
+		code := codes[i%len(codes)]
+		u := User{
+			Login:   fmt.Sprintf("user%d", i),
+			Active:  i%3 != 0,
+			Country: reg.MustIntern(code), // "" is Unknown
+		}
+		users = append(users, u)
+		active = append(active, u.Active)
+		all = append(all, code)
+	}
+	if reg.Len() != 6 {
+		t.Fatalf("expected 6 IDs, got %d", reg.Len())
+	}
+
+	expected := countryCount(all, active)
+	if _, ok := expected[""]; !ok {
+		t.Fatal("no active Unknown users")
+	}
+	if counts := CountryCount(reg, users); !reflect.DeepEqual(counts, expected) {
+		t.Fatalf("expected %v, got %v", expected, counts)
+	}
+}
+
+func TestMustGenerate(t *testing.T) {
+	cfg := dataset.Default()
+	cfg.Size = 1000
+	cfg.Countries = dataset.Codes(20)
+	cfg.Distribution = dataset.Uniform
+
+	reg := country.NewRegistry()
+	users := MustGenerate(reg, cfg)
+	records := dataset.MustGenerate(cfg)
+
+	codes := make([]string, len(records))
+	active := make([]bool, len(records))
+	for i, r := range records {
+		codes[i], active[i] = r.Country, r.Active
+		if code := reg.Code(users[i].Country); code != r.Country {
+			t.Fatalf("%d: expected %q, got %q", i, r.Country, code)
+		}
+	}
+
+	expected := countryCount(codes, active)
+	if counts := CountryCount(reg, users); !reflect.DeepEqual(counts, expected) {
+		t.Fatalf("expected %v, got %v", expected, counts)
+	}
+}
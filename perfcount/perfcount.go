@@ -0,0 +1,96 @@
+// Package perfcount reads hardware performance counters of the current thread
+// and reports them as benchmark metrics.
+package perfcount
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+	"testing"
+)
+
+// ErrUnsupported is returned by Open on platforms without perf_event_open.
+var ErrUnsupported = errors.New("perf counters are not supported on " + runtime.GOOS)
+
+// Event is a hardware event.
+type Event struct {
+	Name   string // used as metric name, e.g. cache-misses/op
+	typ    uint32
+	config uint64
+}
+
+// Value is the count of an event.
+type Value struct {
+	Event Event
+	Count uint64
+}
+
+// DefaultEvents are the events reported by Start.
+var DefaultEvents = []Event{
+	CacheMisses,
+	CacheReferences,
+	LLCLoadMisses,
+	Instructions,
+}
+
+// Bench counts events during a benchmark.
+type Bench struct {
+	b        *testing.B
+	counters *Counters // nil if counters are not available
+}
+
+var logOnce sync.Once
+
+// Start starts counting DefaultEvents for b on the current thread, call it
+// right before the benchmark loop and call Report right after it.
+//
+// If the counters can't be opened (no permission, unsupported platform or
+// hardware) this is logged once and Report does nothing.
+func Start(b *testing.B) *Bench {
+	b.Helper()
+	runtime.LockOSThread() // counters are per thread
+	c, err := Open(DefaultEvents...)
+	if err == nil {
+		err = c.Enable()
+	}
+	if err != nil {
+		if c != nil {
+			c.Close()
+		}
+		runtime.UnlockOSThread()
+		first := false
+		logOnce.Do(func() { first = true })
+		if first {
+			b.Logf("perf counters unavailable: %s", err)
+		}
+		return &Bench{b: b}
+	}
+
+	b.ResetTimer()
+	return &Bench{b: b, counters: c}
+}
+
+// Report stops counting and reports every event as a per iteration metric.
+func (bc *Bench) Report() {
+	if bc.counters == nil {
+		return
+	}
+
+	defer runtime.UnlockOSThread()
+	defer bc.counters.Close()
+
+	if err := bc.counters.Disable(); err != nil {
+		bc.b.Logf("perf counters: %s", err)
+		return
+	}
+
+	values, err := bc.counters.Read()
+	if err != nil {
+		bc.b.Logf("perf counters: %s", err)
+		return
+	}
+
+	for _, v := range values {
+		bc.b.ReportMetric(float64(v.Count)/float64(bc.b.N), v.Event.Name+"/op")
+	}
+}
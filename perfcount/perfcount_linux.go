@@ -0,0 +1,176 @@
+package perfcount
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+// From linux/perf_event.h
+const (
+	perfTypeHardware = 0
+	perfTypeHWCache  = 3
+
+	hwInstructions    = 1
+	hwCacheReferences = 2
+	hwCacheMisses     = 3
+
+	hwCacheLL         = 2
+	hwCacheOpRead     = 0
+	hwCacheResultMiss = 1
+
+	formatTotalTimeEnabled = 1 << 0
+	formatTotalTimeRunning = 1 << 1
+
+	flagDisabled      = 1 << 0
+	flagExcludeKernel = 1 << 5
+	flagExcludeHV     = 1 << 6
+
+	flagFDCloexec = 1 << 3
+
+	iocEnable  = 0x2400
+	iocDisable = 0x2401
+	iocReset   = 0x2403
+)
+
+var (
+	CacheMisses     = Event{"cache-misses", perfTypeHardware, hwCacheMisses}
+	CacheReferences = Event{"cache-references", perfTypeHardware, hwCacheReferences}
+	LLCLoadMisses   = Event{"LLC-load-misses", perfTypeHWCache, hwCacheLL | hwCacheOpRead<<8 | hwCacheResultMiss<<16}
+	Instructions    = Event{"instructions", perfTypeHardware, hwInstructions}
+)
+
+// eventAttr is struct perf_event_attr (PERF_ATTR_SIZE_VER5).
+type eventAttr struct {
+	Type             uint32
+	Size             uint32
+	Config           uint64
+	SamplePeriod     uint64
+	SampleType       uint64
+	ReadFormat       uint64
+	Flags            uint64
+	WakeupEvents     uint32
+	BPType           uint32
+	Config1          uint64
+	Config2          uint64
+	BranchSampleType uint64
+	SampleRegsUser   uint64
+	SampleStackUser  uint32
+	ClockID          int32
+	SampleRegsIntr   uint64
+	AuxWatermark     uint32
+	SampleMaxStack   uint16
+	_                uint16
+}
+
+// Counters are open event counters of the current thread.
+// The caller should lock the goroutine to its thread with runtime.LockOSThread.
+type Counters struct {
+	events []Event
+	fds    []int
+}
+
+// Open opens counters for events on the current thread, user space only.
+// Counters start disabled.
+func Open(events ...Event) (*Counters, error) {
+	c := Counters{events: events}
+	for _, e := range events {
+		fd, err := open(e)
+		if err != nil {
+			c.Close()
+			return nil, fmt.Errorf("open %s: %w", e.Name, err)
+		}
+		c.fds = append(c.fds, fd)
+	}
+
+	return &c, nil
+}
+
+func open(e Event) (int, error) {
+	attr := eventAttr{
+		Type:       e.typ,
+		Config:     e.config,
+		ReadFormat: formatTotalTimeEnabled | formatTotalTimeRunning,
+		Flags:      flagDisabled | flagExcludeKernel | flagExcludeHV,
+	}
+	attr.Size = uint32(unsafe.Sizeof(attr))
+
+	pid, cpu, group := 0, -1, -1 // current thread, any CPU, no group
+	fd, _, errno := syscall.Syscall6(
+		syscall.SYS_PERF_EVENT_OPEN,
+		uintptr(unsafe.Pointer(&attr)),
+		uintptr(pid),
+		uintptr(cpu),
+		uintptr(group),
+		flagFDCloexec,
+		0,
+	)
+	if errno != 0 {
+		return -1, errno
+	}
+
+	return int(fd), nil
+}
+
+// Enable resets and enables all counters.
+func (c *Counters) Enable() error {
+	if err := c.ioctl(iocReset); err != nil {
+		return err
+	}
+
+	return c.ioctl(iocEnable)
+}
+
+// Disable stops all counters.
+func (c *Counters) Disable() error {
+	return c.ioctl(iocDisable)
+}
+
+func (c *Counters) ioctl(req uintptr) error {
+	for _, fd := range c.fds {
+		if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), req, 0); errno != 0 {
+			return fmt.Errorf("ioctl: %w", errno)
+		}
+	}
+
+	return nil
+}
+
+// Read returns the counter values, scaled up if the kernel multiplexed them.
+func (c *Counters) Read() ([]Value, error) {
+	values := make([]Value, len(c.fds))
+	var buf [3]uint64 // value, time enabled, time running
+	data := (*[unsafe.Sizeof(buf)]byte)(unsafe.Pointer(&buf))[:]
+	for i, fd := range c.fds {
+		n, err := syscall.Read(fd, data)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", c.events[i].Name, err)
+		}
+		if n != len(data) {
+			return nil, fmt.Errorf("read %s: short read (%d bytes)", c.events[i].Name, n)
+		}
+
+		count, enabled, running := buf[0], buf[1], buf[2]
+		if running == 0 {
+			count = 0
+		} else if running < enabled {
+			count = uint64(float64(count) * float64(enabled) / float64(running))
+		}
+		values[i] = Value{c.events[i], count}
+	}
+
+	return values, nil
+}
+
+// Close closes all counters.
+func (c *Counters) Close() error {
+	var err error
+	for _, fd := range c.fds {
+		if cerr := syscall.Close(fd); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	c.fds = nil
+
+	return err
+}
@@ -0,0 +1,23 @@
+//go:build !linux
+
+package perfcount
+
+var (
+	CacheMisses     = Event{Name: "cache-misses"}
+	CacheReferences = Event{Name: "cache-references"}
+	LLCLoadMisses   = Event{Name: "LLC-load-misses"}
+	Instructions    = Event{Name: "instructions"}
+)
+
+// Counters are open event counters of the current thread.
+type Counters struct{}
+
+// Open always fails with ErrUnsupported.
+func Open(events ...Event) (*Counters, error) {
+	return nil, ErrUnsupported
+}
+
+func (c *Counters) Enable() error          { return ErrUnsupported }
+func (c *Counters) Disable() error         { return ErrUnsupported }
+func (c *Counters) Read() ([]Value, error) { return nil, ErrUnsupported }
+func (c *Counters) Close() error           { return nil }
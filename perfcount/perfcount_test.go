@@ -0,0 +1,56 @@
+package perfcount
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestCounters(t *testing.T) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	c, err := Open(Instructions)
+	if err != nil {
+		t.Skipf("perf counters unavailable: %s", err)
+	}
+	defer c.Close()
+
+	if err := c.Enable(); err != nil {
+		t.Fatal(err)
+	}
+
+	total := 0
+	for i := 0; i < 1_000_000; i++ {
+		total += i
+	}
+
+	if err := c.Disable(); err != nil {
+		t.Fatal(err)
+	}
+
+	values, err := c.Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(values) != 1 || values[0].Event != Instructions {
+		t.Fatalf("bad values: %+v", values)
+	}
+	if values[0].Count < 1_000_000 {
+		t.Fatalf("expected at least 1M instructions, got %d (total=%d)", values[0].Count, total)
+	}
+}
+
+func BenchmarkStart(b *testing.B) {
+	// Start must not fail the benchmark, even if counters are unavailable.
+	c := Start(b)
+	total := 0
+	for i := 0; i < b.N; i++ {
+		total += i
+	}
+	c.Report()
+
+	if total < 0 {
+		b.Fatal(total)
+	}
+}
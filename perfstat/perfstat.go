@@ -0,0 +1,208 @@
+// Package perfstat parses the output of "perf stat".
+package perfstat
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNoStats is returned when the input has no "Performance counter stats" block.
+var ErrNoStats = errors.New("no perf stat output found")
+
+// Status values of a counter.
+const (
+	Counted      = ""
+	NotCounted   = "not counted"
+	NotSupported = "not supported"
+)
+
+// Counter is a single counter line, e.g.
+//
+//	14,075,274,489      cpu_core/cache-misses:u/          (99.43%)
+type Counter struct {
+	PMU       string  `json:"pmu,omitempty"` // e.g. cpu_core, empty on non hybrid CPUs
+	Event     string  `json:"event"`         // e.g. cache-misses
+	Modifiers string  `json:"modifiers,omitempty"`
+	Value     float64 `json:"value"`
+	Unit      string  `json:"unit,omitempty"` // e.g. msec for task-clock
+	Status    string  `json:"status,omitempty"`
+	// Running is the percent of time the counter was scheduled, it is 100
+	// when perf doesn't print it (no multiplexing).
+	Running float64 `json:"running"`
+	Comment string  `json:"comment,omitempty"` // text after #
+}
+
+// Stats is a "perf stat" block.
+// Durations are serialized to JSON as nanoseconds.
+type Stats struct {
+	Command  string        `json:"command"`
+	Counters []Counter     `json:"counters"`
+	Elapsed  time.Duration `json:"elapsed"`
+	User     time.Duration `json:"user"`
+	Sys      time.Duration `json:"sys"`
+}
+
+const header = "Performance counter stats for "
+
+// Parse parses the first "perf stat" block in r.
+// Lines before the block, such as benchmark output, are ignored.
+func Parse(r io.Reader) (*Stats, error) {
+	s := bufio.NewScanner(r)
+	lnum := 0
+	var st *Stats
+	for s.Scan() {
+		lnum++
+		line := strings.TrimSpace(s.Text())
+		if st == nil {
+			if strings.HasPrefix(line, header) {
+				st = &Stats{Command: parseCommand(line)}
+			}
+			continue
+		}
+
+		if line == "" {
+			continue
+		}
+
+		done, err := st.parseLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("%d: %w", lnum, err)
+		}
+		if done {
+			break
+		}
+	}
+
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	if st == nil {
+		return nil, ErrNoStats
+	}
+
+	return st, nil
+}
+
+// parseCommand returns the command from "Performance counter stats for './users.test -test.bench .':".
+func parseCommand(line string) string {
+	cmd := strings.TrimPrefix(line, header)
+	cmd = strings.TrimSuffix(cmd, ":")
+	return strings.Trim(cmd, "'")
+}
+
+// parseLine parses a counter or timing line, it returns true after the "sys" line.
+func (st *Stats) parseLine(line string) (bool, error) {
+	fields := strings.Fields(line)
+	if len(fields) >= 3 && fields[1] == "seconds" {
+		d, err := parseSeconds(fields[0])
+		if err != nil {
+			return false, fmt.Errorf("%q: %w", line, err)
+		}
+
+		switch strings.Join(fields[2:], " ") {
+		case "time elapsed":
+			st.Elapsed = d
+		case "user":
+			st.User = d
+		case "sys":
+			st.Sys = d
+			return true, nil
+		}
+		return false, nil
+	}
+
+	c, err := parseCounter(line)
+	if err != nil {
+		return false, err
+	}
+	st.Counters = append(st.Counters, c)
+	return false, nil
+}
+
+func parseSeconds(s string) (time.Duration, error) {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(math.Round(v * float64(time.Second))), nil
+}
+
+func parseCounter(line string) (Counter, error) {
+	c := Counter{Running: 100}
+	if i := strings.Index(line, "#"); i != -1 {
+		c.Comment = strings.TrimSpace(line[i+1:])
+		line = line[:i]
+	}
+
+	if i := strings.LastIndex(line, "("); i != -1 && strings.HasSuffix(strings.TrimSpace(line), "%)") {
+		pct := strings.TrimSpace(line[i+1:])
+		pct = strings.TrimSuffix(pct, "%)")
+		v, err := strconv.ParseFloat(strings.TrimSpace(pct), 64)
+		if err != nil {
+			return Counter{}, fmt.Errorf("%q: bad percentage", line)
+		}
+		c.Running = v
+		line = line[:i]
+	}
+
+	switch {
+	case strings.HasPrefix(line, "<not counted>"):
+		c.Status = NotCounted
+		line = strings.TrimPrefix(line, "<not counted>")
+	case strings.HasPrefix(line, "<not supported>"):
+		c.Status = NotSupported
+		line = strings.TrimPrefix(line, "<not supported>")
+	default:
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return Counter{}, fmt.Errorf("%q: bad counter", line)
+		}
+		v, err := strconv.ParseFloat(strings.ReplaceAll(fields[0], ",", ""), 64)
+		if err != nil {
+			return Counter{}, fmt.Errorf("%q: bad value", line)
+		}
+		c.Value = v
+		line = strings.Join(fields[1:], " ")
+	}
+
+	fields := strings.Fields(line)
+	switch len(fields) {
+	case 1:
+	case 2:
+		c.Unit = fields[0]
+	default:
+		return Counter{}, fmt.Errorf("%q: bad counter", line)
+	}
+
+	c.PMU, c.Event, c.Modifiers = parseEvent(fields[len(fields)-1])
+	return c, nil
+}
+
+// parseEvent splits "cpu_core/cache-misses:u/" to PMU, event and modifiers.
+// Events without a PMU look like "cache-misses:u".
+func parseEvent(s string) (pmu, event, mods string) {
+	event = s
+	if i := strings.IndexByte(s, '/'); i != -1 {
+		pmu = s[:i]
+		event = s[i+1:]
+		if j := strings.LastIndexByte(event, '/'); j != -1 {
+			mods = event[j+1:]
+			event = event[:j]
+		}
+	}
+
+	if i := strings.LastIndexByte(event, ':'); i != -1 {
+		mods = event[i+1:] + mods
+		event = event[:i]
+	}
+
+	return pmu, event, mods
+}
@@ -0,0 +1,121 @@
+package perfstat
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseFile(t *testing.T) {
+	file, err := os.Open("../array-perf.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	st, err := Parse(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := "./users.test -test.bench . -test.benchtime=10s -test.count=5"
+	if st.Command != cmd {
+		t.Fatalf("command: got %q", st.Command)
+	}
+
+	expected := []Counter{
+		{PMU: "cpu_core", Event: "cache-misses", Modifiers: "u", Value: 14_075_274_489, Running: 99.43},
+		{PMU: "cpu_atom", Event: "cache-misses", Modifiers: "u", Value: 8_668_379_326, Running: 0.77},
+	}
+	if len(st.Counters) != len(expected) {
+		t.Fatalf("expected %d counters, got %+v", len(expected), st.Counters)
+	}
+	for i, c := range expected {
+		if st.Counters[i] != c {
+			t.Fatalf("counter %d: expected %+v, got %+v", i, c, st.Counters[i])
+		}
+	}
+
+	if st.Elapsed != 58990510463*time.Nanosecond {
+		t.Fatalf("elapsed: got %v", st.Elapsed)
+	}
+	if st.User != 58926667*time.Microsecond {
+		t.Fatalf("user: got %v", st.User)
+	}
+	if st.Sys != 119521*time.Microsecond {
+		t.Fatalf("sys: got %v", st.Sys)
+	}
+}
+
+const generic = `
+ Performance counter stats for 'ls':
+
+              0.71 msec task-clock:u                     #    0.524 CPUs utilized
+           123,456      cache-references                                               (75.10%)
+   <not supported>      LLC-load-misses
+     <not counted>      cpu_atom/instructions/u                                        (0.00%)
+
+       0.001353957 seconds time elapsed
+
+       0.000000000 seconds user
+       0.001417000 seconds sys
+`
+
+func TestParseCounters(t *testing.T) {
+	st, err := Parse(strings.NewReader(generic))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Counter{
+		{Event: "task-clock", Modifiers: "u", Value: 0.71, Unit: "msec", Running: 100, Comment: "0.524 CPUs utilized"},
+		{Event: "cache-references", Value: 123456, Running: 75.10},
+		{Event: "LLC-load-misses", Status: NotSupported, Running: 100},
+		{PMU: "cpu_atom", Event: "instructions", Modifiers: "u", Status: NotCounted, Running: 0},
+	}
+	if len(st.Counters) != len(expected) {
+		t.Fatalf("expected %d counters, got %+v", len(expected), st.Counters)
+	}
+	for i, c := range expected {
+		if st.Counters[i] != c {
+			t.Fatalf("counter %d: expected %+v, got %+v", i, c, st.Counters[i])
+		}
+	}
+}
+
+func TestParseNoStats(t *testing.T) {
+	_, err := Parse(strings.NewReader("PASS\n"))
+	if !errors.Is(err, ErrNoStats) {
+		t.Fatalf("expected ErrNoStats, got %v", err)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	file, err := os.Open("../slice-perf.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	st, err := Parse(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := json.Marshal(st)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out Stats
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+
+	if out.Elapsed != st.Elapsed || len(out.Counters) != 2 || out.Counters[0] != st.Counters[0] {
+		t.Fatalf("round trip: expected %+v, got %+v", st, out)
+	}
+}
@@ -0,0 +1,102 @@
+package users
+
+import (
+	"testing"
+	"unsafe"
+
+	"users/dataset"
+	"users/dataset/datasettest"
+	"users/perfcount"
+)
+
+// maxGap is the maximal number of free User slots between scattered users.
+const maxGap = 4
+
+var (
+	users     []User
+	ptrs      []*User // contiguous
+	scattered []*User
+)
+
+func init() {
+	users = MustGenerate(dataset.Default())
+	ptrs = Contiguous(users)
+	scattered = Scattered(users, maxGap, 1)
+}
+
+func TestContiguous(t *testing.T) {
+	for i := range users {
+		if ptrs[i] != &users[i] {
+			t.Fatalf("%d: expected %p, got %p", i, &users[i], ptrs[i])
+		}
+	}
+}
+
+func TestScattered(t *testing.T) {
+	for i := range users {
+		u, s := users[i], scattered[i]
+		if s == &users[i] {
+			t.Fatalf("%d: not a copy", i)
+		}
+		if s.Login != u.Login || s.Active != u.Active || s.Country != u.Country {
+			t.Fatalf("%d: expected %+v, got %+v", i, u, *s)
+		}
+	}
+}
+
+func TestCountryCount(t *testing.T) {
+	expected := CountryCount(ptrs)
+	if len(expected) == 0 {
+		t.Fatal("no countries")
+	}
+	counts := CountryCount(scattered)
+	for country, n := range expected {
+		if counts[country] != n {
+			t.Fatalf("%s: expected %d, got %d", country, n, counts[country])
+		}
+	}
+}
+
+func BenchmarkCountryCount(b *testing.B) {
+	c := perfcount.Start(b)
+	for i := 0; i < b.N; i++ {
+		m := CountryCount(ptrs)
+		if m == nil {
+			b.Fatal(m)
+		}
+	}
+	c.Report()
+}
+
+func BenchmarkCountryCountScattered(b *testing.B) {
+	c := perfcount.Start(b)
+	for i := 0; i < b.N; i++ {
+		m := CountryCount(scattered)
+		if m == nil {
+			b.Fatal(m)
+		}
+	}
+	c.Report()
+}
+
+func BenchmarkCountryCountSweep(b *testing.B) {
+	// pointer + pointee, gaps not counted
+	footprint := int64(unsafe.Sizeof(&User{}) + unsafe.Sizeof(User{}))
+	// CountryCount reads the pointer, Active and Country.
+	touched := int64(unsafe.Sizeof(&User{}) + unsafe.Sizeof(User{}.Active) + unsafe.Sizeof(User{}.Country))
+	fixtures := []struct {
+		name string
+		ptrs func([]User) []*User
+	}{
+		{"contiguous", Contiguous},
+		{"scattered", func(users []User) []*User { return Scattered(users, maxGap, 1) }},
+	}
+	for _, f := range fixtures {
+		b.Run(f.name, func(b *testing.B) {
+			datasettest.Sweep(b, footprint, touched, func(cfg dataset.Config) func() map[string]int {
+				ptrs := f.ptrs(MustGenerate(cfg))
+				return func() map[string]int { return CountryCount(ptrs) }
+			})
+		})
+	}
+}
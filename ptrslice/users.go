@@ -0,0 +1,56 @@
+package users
+
+import "math/rand"
+
+type Image []byte
+
+type User struct {
+	Login   string
+	Active  bool
+	Icon    Image
+	Country string
+}
+
+// CountryCount returns map of country to number of active users.
+func CountryCount(users []*User) map[string]int {
+	counts := make(map[string]int) // country -> count
+	for _, u := range users {
+		if !u.Active {
+			continue
+		}
+		counts[u.Country]++
+	}
+
+	return counts
+}
+
+// Contiguous returns pointers to users, which stay in a single allocation.
+func Contiguous(users []User) []*User {
+	ptrs := make([]*User, len(users))
+	for i := range users {
+		ptrs[i] = &users[i]
+	}
+
+	return ptrs
+}
+
+// spacers keeps spacer allocations on the heap, see Scattered.
+var spacers []*User
+
+// Scattered returns pointers to individually allocated copies of users.
+// Copies are allocated in random order with up to maxGap User sized gaps
+// between them, so consecutive users are far apart and not in address order.
+func Scattered(users []User, maxGap int, seed int64) []*User {
+	rng := rand.New(rand.NewSource(seed))
+	ptrs := make([]*User, len(users))
+	for _, i := range rng.Perm(len(users)) {
+		u := users[i]
+		ptrs[i] = &u
+		for n := rng.Intn(maxGap + 1); n > 0; n-- {
+			spacers = append(spacers, new(User))
+		}
+	}
+	spacers = nil // gaps become free slots
+
+	return ptrs
+}
@@ -1,30 +1,231 @@
 package users
 
-import "testing"
+import (
+	"fmt"
+	"runtime"
+	"testing"
+	"time"
+	"unsafe"
+
+	"users/dataset"
+	"users/dataset/datasettest"
+	"users/perfcount"
+)
 
 var users []User
 
 func init() {
-	const size = 10_000
-	countries := []string{
-		"AD",
-		"BB",
-		"CA",
-		"DK",
-	}
-	users = make([]User, size)
-	for i := 0; i < size; i++ {
-		users[i].Active = i%5 > 0 // 20% non active
-		users[i].Country = countries[i%len(countries)]
-		users[i].Icon = make([]byte, 128*128)
-	}
+	users = MustGenerate(dataset.Default())
 }
 
 func BenchmarkCountryCount(b *testing.B) {
+	c := perfcount.Start(b)
 	for i := 0; i < b.N; i++ {
 		m := CountryCount(users)
 		if m == nil {
 			b.Fatal(m)
 		}
 	}
+	c.Report()
+}
+
+func BenchmarkCountryCountParallel(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		m := CountryCountParallel(users, 0)
+		if m == nil {
+			b.Fatal(m)
+		}
+	}
+}
+
+// userPtrs returns pointers to users, in order.
+func userPtrs(users []User, order []int) []*User {
+	ptrs := make([]*User, len(order))
+	for i, j := range order {
+		ptrs[i] = &users[j]
+	}
+	return ptrs
+}
+
+func BenchmarkCountryCountRandom(b *testing.B) {
+	order := dataset.Permutation(len(users), 1)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m := CountryCountOrder(users, order)
+		if m == nil {
+			b.Fatal(m)
+		}
+	}
+}
+
+func BenchmarkCountryCountStrided(b *testing.B) {
+	order := dataset.Strided(len(users), 16)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m := CountryCountOrder(users, order)
+		if m == nil {
+			b.Fatal(m)
+		}
+	}
+}
+
+func BenchmarkCountryCountPtr(b *testing.B) {
+	ptrs := userPtrs(users, dataset.Permutation(len(users), 1))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m := CountryCountPtr(ptrs)
+		if m == nil {
+			b.Fatal(m)
+		}
+	}
+}
+
+func BenchmarkCountryCountSweep(b *testing.B) {
+	// CountryCount reads Active and Country.
+	touched := int64(unsafe.Sizeof(User{}.Active) + unsafe.Sizeof(User{}.Country))
+	datasettest.Sweep(b, int64(unsafe.Sizeof(User{})), touched, func(cfg dataset.Config) func() map[string]int {
+		users := MustGenerate(cfg)
+		return func() map[string]int { return CountryCount(users) }
+	})
+}
+
+// benchmarkIcons allocates an icon per user with alloc and reports the time
+// and stop the world pause of a full GC while the icons are live.
+func benchmarkIcons(b *testing.B, alloc func(n int) []Image) {
+	b.ReportAllocs()
+	var before, after runtime.MemStats
+	var gc time.Duration
+	var pause uint64
+	for i := 0; i < b.N; i++ {
+		icons := alloc(len(users))
+		runtime.ReadMemStats(&before)
+		start := time.Now()
+		runtime.GC()
+		gc += time.Since(start)
+		runtime.ReadMemStats(&after)
+		pause += after.PauseTotalNs - before.PauseTotalNs
+		runtime.KeepAlive(icons)
+	}
+	b.ReportMetric(float64(gc.Nanoseconds())/float64(b.N), "gc-ns/op")
+	b.ReportMetric(float64(pause)/float64(b.N), "pause-ns/op")
+}
+
+func BenchmarkIconsMake(b *testing.B) {
+	benchmarkIcons(b, func(n int) []Image {
+		icons := make([]Image, n)
+		for i := range icons {
+			icons[i] = make([]byte, iconSize)
+		}
+		return icons
+	})
+}
+
+func BenchmarkIconsStore(b *testing.B) {
+	benchmarkIcons(b, func(n int) []Image {
+		s := NewIconStore(0)
+		icons := make([]Image, n)
+		for i := range icons {
+			_, icons[i] = s.Alloc()
+		}
+		return icons
+	})
+}
+
+func BenchmarkCountryCountLazy(b *testing.B) {
+	// Same users without resident icons, served by an IconProvider.
+	f, err := OpenIconFile(writeIconFile(b, b.TempDir(), users))
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer f.Close()
+	var p IconProvider = NewIconCache(f, iconUsers)
+
+	lazy := make([]User, len(users))
+	copy(lazy, users)
+	for i := range lazy {
+		lazy[i].Icon = nil
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m := CountryCount(lazy)
+		if m == nil {
+			b.Fatal(m)
+		}
+	}
+	b.StopTimer()
+
+	for _, u := range lazy[:iconUsers] {
+		if icon, err := u.LoadIcon(p); err != nil || !icon.Valid() {
+			b.Fatalf("%s: bad icon (%v)", u.Login, err)
+		}
+	}
+}
+
+// iconUsers is the number of users in icon fetch benchmarks.
+const iconUsers = 1000
+
+// openIconFile returns an icon file with icons of the first iconUsers users.
+func openIconFile(b *testing.B) *IconFile {
+	f, err := OpenIconFile(writeIconFile(b, b.TempDir(), users[:iconUsers]))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { f.Close() })
+	return f
+}
+
+func BenchmarkIconFile(b *testing.B) {
+	f := openIconFile(b)
+	b.SetBytes(iconSize)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		icon, err := f.Icon(users[i%iconUsers].Login)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if len(icon) != iconSize {
+			b.Fatal(len(icon))
+		}
+	}
+}
+
+func BenchmarkIconCache(b *testing.B) {
+	for _, size := range []int{iconUsers / 10, iconUsers} {
+		b.Run(fmt.Sprintf("size=%d", size), func(b *testing.B) {
+			c := NewIconCache(openIconFile(b), size)
+			b.SetBytes(iconSize)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				icon, err := c.Icon(users[i%iconUsers].Login)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if len(icon) != iconSize {
+					b.Fatal(len(icon))
+				}
+			}
+			hits, misses := c.Stats()
+			b.ReportMetric(float64(hits)/float64(hits+misses), "hit-ratio")
+		})
+	}
+}
+
+func BenchmarkIconBlob(b *testing.B) {
+	blob, err := OpenIconBlob(writeIconBlob(b, b.TempDir(), iconUsers))
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer blob.Close()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		icon, err := blob.Icon(i % iconUsers)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if icon[0] != byte(i%iconUsers) {
+			b.Fatal(icon[0])
+		}
+	}
 }
@@ -0,0 +1,126 @@
+package users
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+)
+
+// An icon blob starts with an 8 byte header ("CCBLOBS" and a version byte)
+// followed by fixed size 128x128 icons, icon i is at offset 8 + i*iconSize.
+const (
+	blobMagic   = "CCBLOBS"
+	blobVersion = 1
+	blobHdrSize = len(blobMagic) + 1
+)
+
+// BlobWriter writes an icon blob.
+type BlobWriter struct {
+	w *bufio.Writer
+	n int
+}
+
+// NewBlobWriter writes an icon blob header to w and returns a BlobWriter.
+// Call Flush when done.
+func NewBlobWriter(w io.Writer) (*BlobWriter, error) {
+	bw := BlobWriter{w: bufio.NewWriter(w)}
+	if _, err := bw.w.WriteString(blobMagic); err != nil {
+		return nil, err
+	}
+	if err := bw.w.WriteByte(blobVersion); err != nil {
+		return nil, err
+	}
+
+	return &bw, nil
+}
+
+// Add writes icon as the next record, icon must be valid.
+func (w *BlobWriter) Add(icon Image) error {
+	if !icon.Valid() {
+		return fmt.Errorf("icon %d: %w: %d bytes, expected %d", w.n, ErrIconSize, len(icon), iconSize)
+	}
+	if _, err := w.w.Write(icon); err != nil {
+		return err
+	}
+	w.n++
+
+	return nil
+}
+
+// Len returns the number of icons written.
+func (w *BlobWriter) Len() int {
+	return w.n
+}
+
+// Flush writes buffered data to the underlying writer.
+func (w *BlobWriter) Flush() error {
+	return w.w.Flush()
+}
+
+// IconBlob is an icon blob file mapped to memory. On unix icons are
+// sub-slices of a private mapping, they are not copied to the Go heap and the
+// garbage collector doesn't track them. Changes to icons are private to the
+// process and never written to the file. Without mmap (non unix systems) the
+// file is read to a single heap allocation instead.
+type IconBlob struct {
+	data []byte // the mapping
+	n    int
+}
+
+// OpenIconBlob maps the icon blob at path to memory.
+func OpenIconBlob(path string) (*IconBlob, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close() // the mapping stays valid
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	size := info.Size()
+	if size < int64(blobHdrSize) || (size-int64(blobHdrSize))%iconSize != 0 {
+		return nil, fmt.Errorf("%s: %w: bad size %d", path, ErrBadIconFile, size)
+	}
+
+	data, err := mapFile(file, int(size))
+	if err != nil {
+		return nil, fmt.Errorf("%s: map: %w", path, err)
+	}
+
+	if string(data[:len(blobMagic)]) != blobMagic || data[len(blobMagic)] != blobVersion {
+		unmapFile(data)
+		return nil, fmt.Errorf("%s: %w", path, ErrBadIconFile)
+	}
+
+	return &IconBlob{data: data, n: int(size-int64(blobHdrSize)) / iconSize}, nil
+}
+
+// Len returns the number of icons in the blob.
+func (b *IconBlob) Len() int {
+	return b.n
+}
+
+// Icon returns icon i, it must not be used after Close.
+func (b *IconBlob) Icon(i int) (Image, error) {
+	if i < 0 || i >= b.n {
+		return nil, fmt.Errorf("%w: index %d out of range [0, %d)", ErrNoIcon, i, b.n)
+	}
+
+	off := blobHdrSize + i*iconSize
+	// Limit capacity so append can't write over the next icon.
+	return Image(b.data[off : off+iconSize : off+iconSize]), nil
+}
+
+// Close unmaps the blob.
+func (b *IconBlob) Close() error {
+	if b.data == nil {
+		return nil
+	}
+	data := b.data
+	b.data, b.n = nil, 0
+	return unmapFile(data)
+}
@@ -0,0 +1,21 @@
+//go:build !unix
+
+package users
+
+import (
+	"io"
+	"os"
+)
+
+// mapFile reads size bytes of file to the Go heap, there is no mmap.
+func mapFile(file *os.File, size int) ([]byte, error) {
+	data := make([]byte, size)
+	if _, err := io.ReadFull(file, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func unmapFile(data []byte) error {
+	return nil
+}
@@ -0,0 +1,157 @@
+package users
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// writeIconBlob writes n icons, icon i filled with byte(i), to a blob in dir
+// and returns its path.
+func writeIconBlob(t testing.TB, dir string, n int) string {
+	path := filepath.Join(dir, "icons.blob")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	w, err := NewBlobWriter(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < n; i++ {
+		if err := w.Add(bytes.Repeat([]byte{byte(i)}, iconSize)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestIconBlob(t *testing.T) {
+	const n = 10
+	path := writeIconBlob(t, t.TempDir(), n)
+	b, err := OpenIconBlob(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	if b.Len() != n {
+		t.Fatalf("expected %d icons, got %d", n, b.Len())
+	}
+
+	icons := make([]Image, n)
+	for i := range icons {
+		icon, err := b.Icon(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !icon.Valid() || cap(icon) != iconSize {
+			t.Fatalf("%d: bad icon len %d cap %d", i, len(icon), cap(icon))
+		}
+		if &icon[0] != &b.data[blobHdrSize+i*iconSize] {
+			t.Fatalf("%d: icon is not in the mapping", i)
+		}
+		icons[i] = icon
+	}
+
+	for gc := 0; gc < 3; gc++ {
+		runtime.GC()
+	}
+	for i, icon := range icons {
+		again, _ := b.Icon(i)
+		if &icon[0] != &again[0] {
+			t.Fatalf("%d: icon moved", i)
+		}
+		if icon[0] != byte(i) || icon[iconSize-1] != byte(i) {
+			t.Fatalf("%d: expected pixels %d, got %d & %d", i, i, icon[0], icon[iconSize-1])
+		}
+	}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		if _, err := b.Icon(n / 2); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if allocs != 0 {
+		t.Fatalf("expected 0 allocs, got %v", allocs)
+	}
+
+	// Writes go to a private copy, not to the file.
+	icon, _ := b.Icon(1)
+	if err := icon.Set(0, 0, 0xFF); err != nil {
+		t.Fatal(err)
+	}
+	if v := icon.At(0, 0); v != 0xFF {
+		t.Fatalf("expected 0xFF, got %#x", v)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := data[blobHdrSize+iconSize]; v != 1 {
+		t.Fatalf("write reached the file: %#x", v)
+	}
+
+	for _, i := range []int{-1, n} {
+		if _, err := b.Icon(i); !errors.Is(err, ErrNoIcon) {
+			t.Fatalf("%d: expected %v, got %v", i, ErrNoIcon, err)
+		}
+	}
+
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if b.Len() != 0 {
+		t.Fatalf("expected 0 icons after close, got %d", b.Len())
+	}
+}
+
+func TestBlobWriterSize(t *testing.T) {
+	w, err := NewBlobWriter(&bytes.Buffer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, icon := range []Image{nil, make(Image, iconSize-1), make(Image, iconSize+1)} {
+		if err := w.Add(icon); !errors.Is(err, ErrIconSize) {
+			t.Fatalf("%d bytes: expected %v, got %v", len(icon), ErrIconSize, err)
+		}
+	}
+	if w.Len() != 0 {
+		t.Fatalf("expected 0 icons, got %d", w.Len())
+	}
+}
+
+func TestIconBlobBad(t *testing.T) {
+	dir := t.TempDir()
+	valid, err := os.ReadFile(writeIconBlob(t, dir, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string][]byte{
+		"empty":   nil,
+		"trimmed": valid[:len(valid)-1],
+		"magic":   append([]byte("XXBLOBS"), valid[len(blobMagic):]...),
+	}
+	for name, data := range cases {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := OpenIconBlob(path); !errors.Is(err, ErrBadIconFile) {
+			t.Fatalf("%s: expected %v, got %v", name, ErrBadIconFile, err)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+//go:build unix
+
+package users
+
+import (
+	"os"
+	"syscall"
+)
+
+// mapFile maps size bytes of file copy-on-write: writes to the mapping
+// don't fault and are not written back to file.
+func mapFile(file *os.File, size int) ([]byte, error) {
+	return syscall.Mmap(int(file.Fd()), 0, size, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_PRIVATE)
+}
+
+func unmapFile(data []byte) error {
+	return syscall.Munmap(data)
+}
@@ -0,0 +1,87 @@
+package users
+
+import (
+	"container/list"
+	"sync"
+)
+
+// IconCache is an IconProvider keeping the last used icons of another
+// provider in memory. It is safe for concurrent use.
+type IconCache struct {
+	p    IconProvider
+	size int
+
+	mu     sync.Mutex
+	lru    *list.List               // of *cacheEntry, front is most recently used
+	items  map[string]*list.Element // login -> element in lru
+	hits   int
+	misses int
+}
+
+type cacheEntry struct {
+	login string
+	icon  Image
+}
+
+// NewIconCache returns a cache of up to size icons loaded from p.
+func NewIconCache(p IconProvider, size int) *IconCache {
+	if size < 1 {
+		size = 1
+	}
+	return &IconCache{
+		p:     p,
+		size:  size,
+		lru:   list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+// Icon returns the icon of login, loading it from the provider on a miss.
+// Errors are not cached.
+func (c *IconCache) Icon(login string) (Image, error) {
+	c.mu.Lock()
+	if e, ok := c.items[login]; ok {
+		c.lru.MoveToFront(e)
+		c.hits++
+		icon := e.Value.(*cacheEntry).icon
+		c.mu.Unlock()
+		return icon, nil
+	}
+	c.misses++
+	c.mu.Unlock()
+
+	// Don't hold the lock while loading.
+	icon, err := c.p.Icon(login)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.items[login]; ok { // loaded by another goroutine
+		c.lru.MoveToFront(e)
+		return e.Value.(*cacheEntry).icon, nil
+	}
+	c.items[login] = c.lru.PushFront(&cacheEntry{login, icon})
+	if c.lru.Len() > c.size {
+		e := c.lru.Back()
+		c.lru.Remove(e)
+		delete(c.items, e.Value.(*cacheEntry).login)
+	}
+
+	return icon, nil
+}
+
+// Len returns the number of cached icons.
+func (c *IconCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// Stats returns the number of cache hits and misses.
+func (c *IconCache) Stats() (hits, misses int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses
+}
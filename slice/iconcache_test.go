@@ -0,0 +1,57 @@
+package users
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// countingProvider returns a 1 byte icon holding the number of loads.
+type countingProvider struct {
+	loads int
+}
+
+func (p *countingProvider) Icon(login string) (Image, error) {
+	if login == "" {
+		return nil, fmt.Errorf("%w: %q", ErrNoIcon, login)
+	}
+	p.loads++
+	return Image{byte(p.loads)}, nil
+}
+
+func TestIconCache(t *testing.T) {
+	p := &countingProvider{}
+	c := NewIconCache(p, 2)
+
+	for _, login := range []string{"a", "b", "a", "c", "b", "a"} {
+		if _, err := c.Icon(login); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// a miss, b miss, a hit, c miss (evicts b), b miss (evicts a), a miss (evicts c)
+	hits, misses := c.Stats()
+	if hits != 1 || misses != 5 {
+		t.Fatalf("expected 1 hit 5 misses, got %d hits %d misses", hits, misses)
+	}
+	if p.loads != 5 {
+		t.Fatalf("expected 5 loads, got %d", p.loads)
+	}
+	if c.Len() != 2 {
+		t.Fatalf("expected 2 cached icons, got %d", c.Len())
+	}
+
+	icon, err := c.Icon("b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if icon[0] != 4 {
+		t.Fatalf("expected cached icon 4, got %d", icon[0])
+	}
+
+	if _, err := c.Icon(""); !errors.Is(err, ErrNoIcon) {
+		t.Fatalf("expected %v, got %v", ErrNoIcon, err)
+	}
+	if c.Len() != 2 {
+		t.Fatalf("error cached: %d icons", c.Len())
+	}
+}
@@ -0,0 +1,236 @@
+package users
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// An icon file starts with an 8 byte header ("CCICONS" and a version byte)
+// followed by the icons and an index. The index is the number of icons and,
+// for each icon, the login, offset and length, all as unsigned varints
+// (login as length then bytes). The file ends with the index offset as an
+// 8 byte little endian integer.
+const (
+	iconMagic   = "CCICONS"
+	iconVersion = 1
+	iconHdrSize = len(iconMagic) + 1
+)
+
+var (
+	ErrBadIconFile = errors.New("not an icon file")
+	ErrNoIcon      = errors.New("no icon")
+)
+
+// IconProvider returns user icons on demand, so they don't have to be
+// resident in User.Icon.
+type IconProvider interface {
+	// Icon returns the icon of login, or an error wrapping ErrNoIcon.
+	Icon(login string) (Image, error)
+}
+
+// LoadIcon returns u.Icon if set, otherwise the icon p has for u.Login.
+// The loaded icon is not stored in u.
+func (u *User) LoadIcon(p IconProvider) (Image, error) {
+	if u.Icon != nil {
+		return u.Icon, nil
+	}
+	return p.Icon(u.Login)
+}
+
+type iconEntry struct {
+	off int64
+	len int64
+}
+
+// IconWriter writes an icon file.
+type IconWriter struct {
+	w      *bufio.Writer
+	off    int64
+	logins []string
+	index  []iconEntry
+}
+
+// NewIconWriter writes an icon file header to w and returns an IconWriter.
+// Call Close when done.
+func NewIconWriter(w io.Writer) (*IconWriter, error) {
+	iw := IconWriter{w: bufio.NewWriter(w), off: int64(iconHdrSize)}
+	if _, err := iw.w.WriteString(iconMagic); err != nil {
+		return nil, err
+	}
+	if err := iw.w.WriteByte(iconVersion); err != nil {
+		return nil, err
+	}
+
+	return &iw, nil
+}
+
+// Add writes the icon of login.
+func (w *IconWriter) Add(login string, icon Image) error {
+	if _, err := w.w.Write(icon); err != nil {
+		return err
+	}
+	w.logins = append(w.logins, login)
+	w.index = append(w.index, iconEntry{w.off, int64(len(icon))})
+	w.off += int64(len(icon))
+
+	return nil
+}
+
+// Close writes the index and flushes w, it does not close the underlying writer.
+func (w *IconWriter) Close() error {
+	var buf [binary.MaxVarintLen64]byte
+	putUvarint := func(v uint64) {
+		n := binary.PutUvarint(buf[:], v)
+		w.w.Write(buf[:n]) // error is sticky, checked by Flush
+	}
+
+	putUvarint(uint64(len(w.index)))
+	for i, e := range w.index {
+		putUvarint(uint64(len(w.logins[i])))
+		w.w.WriteString(w.logins[i])
+		putUvarint(uint64(e.off))
+		putUvarint(uint64(e.len))
+	}
+	binary.LittleEndian.PutUint64(buf[:8], uint64(w.off))
+	w.w.Write(buf[:8])
+
+	return w.w.Flush()
+}
+
+// IconFile is an IconProvider reading icons from an icon file.
+// Only the index is kept in memory, it is safe for concurrent use.
+type IconFile struct {
+	r     io.ReaderAt
+	index map[string]iconEntry // login -> entry
+	c     io.Closer
+}
+
+// OpenIconFile opens the icon file at path.
+func OpenIconFile(path string) (*IconFile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	f, err := NewIconFile(file, info.Size())
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	f.c = file
+
+	return f, nil
+}
+
+// NewIconFile reads the header and index of an icon file of size bytes from r.
+func NewIconFile(r io.ReaderAt, size int64) (*IconFile, error) {
+	if size < int64(iconHdrSize)+8 {
+		return nil, fmt.Errorf("%w: short file", ErrBadIconFile)
+	}
+
+	var hdr [iconHdrSize]byte
+	if _, err := r.ReadAt(hdr[:], 0); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrBadIconFile, err)
+	}
+	if string(hdr[:len(iconMagic)]) != iconMagic {
+		return nil, ErrBadIconFile
+	}
+	if v := hdr[len(iconMagic)]; v != iconVersion {
+		return nil, fmt.Errorf("%w: unknown version %d", ErrBadIconFile, v)
+	}
+
+	var trailer [8]byte
+	if _, err := r.ReadAt(trailer[:], size-8); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrBadIconFile, err)
+	}
+	indexOff := int64(binary.LittleEndian.Uint64(trailer[:]))
+	if indexOff < int64(iconHdrSize) || indexOff > size-8 {
+		return nil, fmt.Errorf("%w: bad index offset %d", ErrBadIconFile, indexOff)
+	}
+
+	indexLen := size - 8 - indexOff
+	index, err := readIndex(bufio.NewReader(io.NewSectionReader(r, indexOff, indexLen)), indexOff, indexLen)
+	if err != nil {
+		return nil, fmt.Errorf("%w: index: %s", ErrBadIconFile, err)
+	}
+
+	return &IconFile{r: r, index: index}, nil
+}
+
+// readIndex reads an index of indexLen bytes, for icons stored before end.
+func readIndex(r *bufio.Reader, end, indexLen int64) (map[string]iconEntry, error) {
+	count, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(map[string]iconEntry)
+	for i := uint64(0); i < count; i++ {
+		n, err := binary.ReadUvarint(r)
+		if err != nil {
+			return nil, err
+		}
+		if n > uint64(indexLen) {
+			return nil, fmt.Errorf("login too long (%d)", n)
+		}
+		login := make([]byte, n)
+		if _, err := io.ReadFull(r, login); err != nil {
+			return nil, err
+		}
+
+		var e iconEntry
+		off, err := binary.ReadUvarint(r)
+		if err != nil {
+			return nil, err
+		}
+		length, err := binary.ReadUvarint(r)
+		if err != nil {
+			return nil, err
+		}
+		e.off, e.len = int64(off), int64(length)
+		if e.off < int64(iconHdrSize) || e.off > end || e.len < 0 || e.len > end-e.off {
+			return nil, fmt.Errorf("%q: icon out of range", login)
+		}
+		index[string(login)] = e
+	}
+
+	return index, nil
+}
+
+// Icon returns the icon of login, read from the file.
+func (f *IconFile) Icon(login string) (Image, error) {
+	e, ok := f.index[login]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrNoIcon, login)
+	}
+
+	icon := make(Image, e.len)
+	if _, err := f.r.ReadAt(icon, e.off); err != nil {
+		return nil, err
+	}
+
+	return icon, nil
+}
+
+// Len returns the number of icons in the file.
+func (f *IconFile) Len() int {
+	return len(f.index)
+}
+
+// Close closes the file opened by OpenIconFile, it is a no-op for NewIconFile.
+func (f *IconFile) Close() error {
+	if f.c == nil {
+		return nil
+	}
+	return f.c.Close()
+}
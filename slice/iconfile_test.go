@@ -0,0 +1,156 @@
+package users
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeIconFile writes icons for users to an icon file in dir and returns its path.
+func writeIconFile(t testing.TB, dir string, users []User) string {
+	path := filepath.Join(dir, "icons.bin")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	w, err := NewIconWriter(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, u := range users {
+		if err := w.Add(u.Login, u.Icon); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func testIconUsers() []User {
+	users := []User{
+		{Login: "bugs", Icon: make(Image, iconSize)},
+		{Login: "daffy", Icon: make(Image, iconSize)},
+		{Login: "taz", Icon: Image{1, 2, 3}},
+		{Login: "elmer", Icon: Image{}},
+	}
+	users[0].Icon[0] = 1
+	users[1].Icon[iconSize-1] = 2
+	return users
+}
+
+func TestIconFile(t *testing.T) {
+	users := testIconUsers()
+	f, err := OpenIconFile(writeIconFile(t, t.TempDir(), users))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if f.Len() != len(users) {
+		t.Fatalf("expected %d icons, got %d", len(users), f.Len())
+	}
+	for _, u := range users {
+		icon, err := f.Icon(u.Login)
+		if err != nil {
+			t.Fatalf("%s: %v", u.Login, err)
+		}
+		if !icon.Equal(u.Icon) {
+			t.Fatalf("%s: icon mismatch", u.Login)
+		}
+	}
+
+	if _, err := f.Icon("porky"); !errors.Is(err, ErrNoIcon) {
+		t.Fatalf("expected %v, got %v", ErrNoIcon, err)
+	}
+}
+
+func TestIconFileBad(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewIconWriter(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Add("bugs", make(Image, 10)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+
+	badVersion := bytes.Clone(data)
+	badVersion[len(iconMagic)]++
+	badIndex := bytes.Clone(data)
+	badIndex[len(badIndex)-8] = 0xFF
+
+	// index entry with a length that overflows offset+length
+	huge := append([]byte(iconMagic), iconVersion)
+	huge = append(huge, make([]byte, 10)...)
+	indexOff := len(huge)
+	huge = append(huge, 1, 1, 'a', byte(iconHdrSize))
+	huge = binary.AppendUvarint(huge, math.MaxInt64)
+	huge = binary.LittleEndian.AppendUint64(huge, uint64(indexOff))
+
+	cases := map[string][]byte{
+		"empty":   nil,
+		"length":  huge,
+		"magic":   append([]byte("XXICONS"), data[len(iconMagic):]...),
+		"version": badVersion,
+		"index":   badIndex,
+		"trimmed": data[:len(data)-1],
+	}
+	for name, data := range cases {
+		_, err := NewIconFile(bytes.NewReader(data), int64(len(data)))
+		if !errors.Is(err, ErrBadIconFile) {
+			t.Fatalf("%s: expected %v, got %v", name, ErrBadIconFile, err)
+		}
+	}
+}
+
+func TestLoadIcon(t *testing.T) {
+	users := testIconUsers()
+	var buf bytes.Buffer
+	w, err := NewIconWriter(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Add(users[0].Login, users[0].Icon); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f, err := NewIconFile(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u := User{Login: users[0].Login}
+	icon, err := u.LoadIcon(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !icon.Equal(users[0].Icon) {
+		t.Fatal("icon mismatch")
+	}
+	if u.Icon != nil {
+		t.Fatal("icon stored in user")
+	}
+
+	resident := User{Login: "taz", Icon: Image{7}}
+	if icon, err := resident.LoadIcon(f); err != nil || icon[0] != 7 {
+		t.Fatalf("expected resident icon, got %v (%v)", icon, err)
+	}
+}
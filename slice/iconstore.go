@@ -0,0 +1,87 @@
+package users
+
+// DefaultArenaIcons is the number of icons in an arena (4MiB).
+const DefaultArenaIcons = 256
+
+// Handle identifies an icon in an IconStore, the zero Handle is no icon.
+type Handle uint32
+
+// IconStore allocates icons from large arenas instead of one heap object per
+// icon, which leaves the garbage collector with few large objects to track.
+// Freed icons are reused by later allocations.
+type IconStore struct {
+	arenaIcons int
+	arenas     [][]byte
+	used       []bool // handle-1 -> in use
+	free       []Handle
+	live       int
+}
+
+// NewIconStore returns an empty store allocating arenaIcons icons at a time,
+// if arenaIcons < 1 DefaultArenaIcons is used.
+func NewIconStore(arenaIcons int) *IconStore {
+	if arenaIcons < 1 {
+		arenaIcons = DefaultArenaIcons
+	}
+	return &IconStore{arenaIcons: arenaIcons}
+}
+
+// Alloc returns a handle to a new zeroed icon and the icon itself.
+func (s *IconStore) Alloc() (Handle, Image) {
+	var h Handle
+	if n := len(s.free); n > 0 {
+		h = s.free[n-1]
+		s.free = s.free[:n-1]
+		icon := s.Get(h)
+		for i := range icon {
+			icon[i] = 0
+		}
+	} else {
+		if len(s.used) == len(s.arenas)*s.arenaIcons {
+			s.arenas = append(s.arenas, make([]byte, s.arenaIcons*iconSize))
+		}
+		s.used = append(s.used, false)
+		h = Handle(len(s.used))
+	}
+	s.used[h-1] = true
+	s.live++
+
+	return h, s.Get(h)
+}
+
+// Get returns the icon of h, or nil for the zero Handle.
+// The icon is valid until h is freed.
+func (s *IconStore) Get(h Handle) Image {
+	if h == 0 {
+		return nil
+	}
+	i := int(h - 1)
+	arena := s.arenas[i/s.arenaIcons]
+	off := (i % s.arenaIcons) * iconSize
+	// Limit capacity so append can't write over the next icon.
+	return Image(arena[off : off+iconSize : off+iconSize])
+}
+
+// Free releases the icon of h for reuse, freeing the zero Handle is a no-op.
+// It panics if h is not allocated.
+func (s *IconStore) Free(h Handle) {
+	if h == 0 {
+		return
+	}
+	if int(h) > len(s.used) || !s.used[h-1] {
+		panic("users: free of unallocated icon handle")
+	}
+	s.used[h-1] = false
+	s.free = append(s.free, h)
+	s.live--
+}
+
+// Len returns the number of allocated icons.
+func (s *IconStore) Len() int {
+	return s.live
+}
+
+// Cap returns the number of icons the store can hold without a new arena.
+func (s *IconStore) Cap() int {
+	return len(s.arenas) * s.arenaIcons
+}
@@ -0,0 +1,67 @@
+package users
+
+import "testing"
+
+func TestIconStore(t *testing.T) {
+	s := NewIconStore(2)
+	var handles []Handle
+	for i := 0; i < 5; i++ {
+		h, icon := s.Alloc()
+		if len(icon) != iconSize || cap(icon) != iconSize {
+			t.Fatalf("%d: expected len & cap %d, got %d & %d", i, iconSize, len(icon), cap(icon))
+		}
+		icon[0] = byte(i + 1)
+		handles = append(handles, h)
+	}
+	if s.Len() != 5 {
+		t.Fatalf("expected len 5, got %d", s.Len())
+	}
+	if s.Cap() != 6 {
+		t.Fatalf("expected cap 6, got %d", s.Cap())
+	}
+
+	for i, h := range handles {
+		if v := s.Get(h)[0]; v != byte(i+1) {
+			t.Fatalf("%d: expected %d, got %d", i, i+1, v)
+		}
+	}
+
+	if s.Get(0) != nil {
+		t.Fatal("expected nil icon for zero handle")
+	}
+}
+
+func TestIconStoreFree(t *testing.T) {
+	s := NewIconStore(0)
+	h1, icon := s.Alloc()
+	icon[7] = 7
+	s.Free(h1)
+	s.Free(0)
+	if s.Len() != 0 {
+		t.Fatalf("expected len 0, got %d", s.Len())
+	}
+
+	h2, icon := s.Alloc()
+	if h2 != h1 {
+		t.Fatalf("expected reuse of %d, got %d", h1, h2)
+	}
+	if icon[7] != 0 {
+		t.Fatalf("expected zeroed icon, got %d", icon[7])
+	}
+	if s.Cap() != DefaultArenaIcons {
+		t.Fatalf("expected cap %d, got %d", DefaultArenaIcons, s.Cap())
+	}
+}
+
+func TestIconStoreDoubleFree(t *testing.T) {
+	s := NewIconStore(0)
+	h, _ := s.Alloc()
+	s.Free(h)
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic")
+		}
+	}()
+	s.Free(h)
+}
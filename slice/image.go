@@ -0,0 +1,88 @@
+package users
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+const (
+	// iconSide is the width and height of an icon in pixels.
+	iconSide = 128
+	// iconSize is the size of an icon in bytes (128x128 grayscale).
+	iconSize = iconSide * iconSide
+)
+
+var (
+	ErrIconSize   = errors.New("bad icon size")
+	ErrOutOfRange = errors.New("pixel out of range")
+)
+
+// Valid reports whether img is a 128x128 icon. A nil img is an empty icon,
+// it is not valid but all methods handle it.
+func (img Image) Valid() bool {
+	return len(img) == iconSize
+}
+
+// Width returns the width of img in pixels, 0 if img is not valid.
+func (img Image) Width() int {
+	if !img.Valid() {
+		return 0
+	}
+	return iconSide
+}
+
+// Height returns the height of img in pixels, 0 if img is not valid.
+func (img Image) Height() int {
+	if !img.Valid() {
+		return 0
+	}
+	return iconSide
+}
+
+// At returns the pixel at x, y or 0 if it is out of range or img is not valid.
+func (img Image) At(x, y int) byte {
+	if !img.in(x, y) {
+		return 0
+	}
+	return img[y*iconSide+x]
+}
+
+// Set sets the pixel at x, y to v.
+func (img Image) Set(x, y int, v byte) error {
+	if !img.Valid() {
+		return fmt.Errorf("%w: %d bytes", ErrIconSize, len(img))
+	}
+	if !img.in(x, y) {
+		return fmt.Errorf("%w: (%d, %d)", ErrOutOfRange, x, y)
+	}
+	img[y*iconSide+x] = v
+	return nil
+}
+
+func (img Image) in(x, y int) bool {
+	return img.Valid() && x >= 0 && x < iconSide && y >= 0 && y < iconSide
+}
+
+// Clone returns a copy of img, nil if img is nil.
+func (img Image) Clone() Image {
+	if img == nil {
+		return nil
+	}
+	return append(Image(make([]byte, 0, len(img))), img...)
+}
+
+// Equal reports whether img and other have the same pixels, a nil Image is
+// equal to an empty one.
+func (img Image) Equal(other Image) bool {
+	return bytes.Equal(img, other)
+}
+
+// Validate returns an error if u has an icon of the wrong size.
+// A nil icon means the user has no icon and is valid.
+func (u *User) Validate() error {
+	if u.Icon != nil && !u.Icon.Valid() {
+		return fmt.Errorf("user %q: %w: %d bytes, expected %d", u.Login, ErrIconSize, len(u.Icon), iconSize)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package users
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestImageNil(t *testing.T) {
+	var img Image
+	if img.Valid() {
+		t.Fatal("nil image is valid")
+	}
+	if img.Width() != 0 || img.Height() != 0 {
+		t.Fatalf("expected 0x0, got %dx%d", img.Width(), img.Height())
+	}
+	if v := img.At(0, 0); v != 0 {
+		t.Fatalf("expected 0, got %d", v)
+	}
+	if err := img.Set(0, 0, 1); !errors.Is(err, ErrIconSize) {
+		t.Fatalf("expected %v, got %v", ErrIconSize, err)
+	}
+	if img.Clone() != nil {
+		t.Fatal("expected nil clone")
+	}
+	if !img.Equal(Image{}) {
+		t.Fatal("nil image not equal to empty image")
+	}
+}
+
+func TestImageSize(t *testing.T) {
+	cases := []struct {
+		name  string
+		size  int
+		valid bool
+	}{
+		{"empty", 0, false},
+		{"short", iconSize - 1, false},
+		{"exact", iconSize, true},
+		{"oversized", iconSize + 1, false},
+	}
+	for _, tc := range cases {
+		img := make(Image, tc.size)
+		if img.Valid() != tc.valid {
+			t.Fatalf("%s: expected valid %v, got %v", tc.name, tc.valid, img.Valid())
+		}
+		if err := img.Set(1, 1, 1); (err == nil) != tc.valid {
+			t.Fatalf("%s: set: %v", tc.name, err)
+		}
+
+		u := User{Login: tc.name, Icon: img}
+		if err := u.Validate(); (err == nil) != tc.valid {
+			t.Fatalf("%s: validate: %v", tc.name, err)
+		}
+	}
+
+	if err := (&User{}).Validate(); err != nil {
+		t.Fatalf("user without icon: %v", err)
+	}
+}
+
+func TestImagePixels(t *testing.T) {
+	img := make(Image, iconSize)
+	if img.Width() != iconSide || img.Height() != iconSide {
+		t.Fatalf("expected %dx%d, got %dx%d", iconSide, iconSide, img.Width(), img.Height())
+	}
+	if err := img.Set(3, 2, 7); err != nil {
+		t.Fatal(err)
+	}
+	if v := img.At(3, 2); v != 7 {
+		t.Fatalf("expected 7, got %d", v)
+	}
+	if v := img[2*iconSide+3]; v != 7 {
+		t.Fatalf("expected row major pixel 7, got %d", v)
+	}
+
+	for _, p := range [][2]int{{-1, 0}, {0, -1}, {iconSide, 0}, {0, iconSide}} {
+		if err := img.Set(p[0], p[1], 1); !errors.Is(err, ErrOutOfRange) {
+			t.Fatalf("%v: expected %v, got %v", p, ErrOutOfRange, err)
+		}
+		if v := img.At(p[0], p[1]); v != 0 {
+			t.Fatalf("%v: expected 0, got %d", p, v)
+		}
+	}
+
+	clone := img.Clone()
+	if !clone.Equal(img) {
+		t.Fatal("clone not equal")
+	}
+	clone[0] = 1
+	if img[0] != 0 || clone.Equal(img) {
+		t.Fatal("clone shares memory")
+	}
+}
@@ -0,0 +1,29 @@
+package users
+
+// CountryCountOrder is like CountryCount but visits users in order, where
+// order holds indices into users (e.g. a random permutation).
+func CountryCountOrder(users []User, order []int) map[string]int {
+	counts := make(map[string]int) // country -> count
+	for _, i := range order {
+		u := &users[i]
+		if !u.Active {
+			continue
+		}
+		counts[u.Country]++
+	}
+
+	return counts
+}
+
+// CountryCountPtr is like CountryCount but reaches every user through a pointer.
+func CountryCountPtr(users []*User) map[string]int {
+	counts := make(map[string]int) // country -> count
+	for _, u := range users {
+		if !u.Active {
+			continue
+		}
+		counts[u.Country]++
+	}
+
+	return counts
+}
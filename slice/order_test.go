@@ -0,0 +1,25 @@
+package users
+
+import (
+	"reflect"
+	"testing"
+
+	"users/dataset"
+)
+
+func TestCountryCountOrder(t *testing.T) {
+	expected := CountryCount(users)
+	orders := map[string][]int{
+		"random":  dataset.Permutation(len(users), 1),
+		"strided": dataset.Strided(len(users), 16),
+	}
+	for name, order := range orders {
+		if got := CountryCountOrder(users, order); !reflect.DeepEqual(got, expected) {
+			t.Errorf("%s: expected %v, got %v", name, expected, got)
+		}
+	}
+
+	if got := CountryCountPtr(userPtrs(users, orders["random"])); !reflect.DeepEqual(got, expected) {
+		t.Errorf("pointers: expected %v, got %v", expected, got)
+	}
+}
@@ -0,0 +1,90 @@
+package users
+
+import (
+	"runtime"
+	"sync"
+	"unsafe"
+)
+
+// cacheLineSize is the coherency size from cache.txt.
+const cacheLineSize = 64
+
+// shardCountries is the number of countries a shard counts in place, more
+// countries spill to a map.
+const shardCountries = 8
+
+// countTable is a worker local country count table.
+type countTable struct {
+	countries [shardCountries]string
+	counts    [shardCountries]int
+	n         int            // used slots
+	spill     map[string]int // country -> count, past shardCountries
+}
+
+// add counts a user from country.
+func (c *countTable) add(country string) {
+	for i := 0; i < c.n; i++ {
+		if c.countries[i] == country {
+			c.counts[i]++
+			return
+		}
+	}
+
+	if c.n < shardCountries {
+		c.countries[c.n] = country
+		c.counts[c.n] = 1
+		c.n++
+		return
+	}
+
+	if c.spill == nil {
+		c.spill = make(map[string]int)
+	}
+	c.spill[country]++
+}
+
+// shard is a worker's count table, padded to whole cache lines so workers
+// incrementing counts don't write to the same line.
+type shard struct {
+	countTable
+	_ [cacheLineSize - unsafe.Sizeof(countTable{})%cacheLineSize]byte
+}
+
+// CountryCountParallel is like CountryCount but splits users between workers
+// goroutines. If workers < 1, runtime.GOMAXPROCS(0) workers are used.
+func CountryCountParallel(users []User, workers int) map[string]int {
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > len(users) {
+		workers = len(users)
+	}
+
+	shards := make([]shard, workers)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		start, end := w*len(users)/workers, (w+1)*len(users)/workers
+		wg.Add(1)
+		go func(s *shard, users []User) {
+			defer wg.Done()
+			for i := range users {
+				if users[i].Active {
+					s.add(users[i].Country)
+				}
+			}
+		}(&shards[w], users[start:end])
+	}
+	wg.Wait()
+
+	total := make(map[string]int) // country -> count
+	for _, s := range shards {
+		for i := 0; i < s.n; i++ {
+			total[s.countries[i]] += s.counts[i]
+		}
+		for country, n := range s.spill {
+			total[country] += n
+		}
+	}
+
+	return total
+}
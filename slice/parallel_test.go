@@ -0,0 +1,47 @@
+package users
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"unsafe"
+)
+
+func TestShardSize(t *testing.T) {
+	if size := unsafe.Sizeof(shard{}); size%cacheLineSize != 0 {
+		t.Fatalf("shard size: expected a multiple of %d, got %d", cacheLineSize, size)
+	}
+}
+
+func TestCountryCountParallelSpill(t *testing.T) {
+	many := make([]User, 1000)
+	for i := range many {
+		many[i].Active = i%5 != 0
+		many[i].Country = fmt.Sprintf("C%d", i%(3*shardCountries))
+	}
+
+	for _, w := range []int{1, 3} {
+		expected := CountryCount(many)
+		got := CountryCountParallel(many, w)
+		if !reflect.DeepEqual(expected, got) {
+			t.Fatalf("workers=%d: expected %v, got %v", w, expected, got)
+		}
+	}
+}
+
+func TestCountryCountParallel(t *testing.T) {
+	sizes := []int{0, 1, 7, 100, len(users)}
+	workers := []int{-1, 0, 1, 2, 3, 12, 200}
+	for _, size := range sizes {
+		for _, w := range workers {
+			name := fmt.Sprintf("size=%d/workers=%d", size, w)
+			t.Run(name, func(t *testing.T) {
+				expected := CountryCount(users[:size])
+				got := CountryCountParallel(users[:size], w)
+				if !reflect.DeepEqual(expected, got) {
+					t.Fatalf("expected %v, got %v", expected, got)
+				}
+			})
+		}
+	}
+}
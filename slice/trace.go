@@ -0,0 +1,31 @@
+package users
+
+import (
+	"unsafe"
+
+	"users/addrtrace"
+)
+
+// CountryCountTrace is CountryCount that writes every read it makes to w:
+// Active, the Country string header, the Country bytes and an approximation
+// of the map bucket. Write errors are returned by w.Flush.
+func CountryCountTrace(users []User, w *addrtrace.Writer) map[string]int {
+	counts := make(map[string]int) // country -> count
+	buckets := addrtrace.NewMap(*(*unsafe.Pointer)(unsafe.Pointer(&counts)))
+	for i := range users {
+		u := &users[i]
+		w.Read(unsafe.Pointer(&u.Active), unsafe.Sizeof(u.Active), addrtrace.Active)
+		if !u.Active {
+			continue
+		}
+
+		w.Read(unsafe.Pointer(&u.Country), unsafe.Sizeof(u.Country), addrtrace.CountryHeader)
+		if len(u.Country) > 0 {
+			w.Read(unsafe.Pointer(unsafe.StringData(u.Country)), uintptr(len(u.Country)), addrtrace.CountryBytes)
+		}
+		w.Write(buckets.Access(u.Country))
+		counts[u.Country]++
+	}
+
+	return counts
+}
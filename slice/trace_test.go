@@ -0,0 +1,68 @@
+package users
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+	"unsafe"
+
+	"users/addrtrace"
+)
+
+func TestCountryCountTrace(t *testing.T) {
+	sample := users[:100]
+
+	var buf bytes.Buffer
+	w, err := addrtrace.NewWriter(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counts := CountryCountTrace(sample, w)
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := CountryCount(sample); !reflect.DeepEqual(counts, expected) {
+		t.Fatalf("expected %v, got %v", expected, counts)
+	}
+
+	r, err := addrtrace.NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var accesses []addrtrace.Access
+	for {
+		a, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		accesses = append(accesses, a)
+	}
+
+	if len(accesses) != w.Count() {
+		t.Fatalf("expected %d accesses, got %d", w.Count(), len(accesses))
+	}
+
+	// First user is not active: a single Active read.
+	// Second user is active: Active, Country header, Country bytes, map bucket.
+	expected := []addrtrace.Access{
+		{Addr: uint64(uintptr(unsafe.Pointer(&sample[0].Active))), Size: 1, Kind: addrtrace.Active},
+		{Addr: uint64(uintptr(unsafe.Pointer(&sample[1].Active))), Size: 1, Kind: addrtrace.Active},
+		{Addr: uint64(uintptr(unsafe.Pointer(&sample[1].Country))), Size: 16, Kind: addrtrace.CountryHeader},
+		{Addr: uint64(uintptr(unsafe.Pointer(unsafe.StringData(sample[1].Country)))), Size: 2, Kind: addrtrace.CountryBytes},
+	}
+	for i, e := range expected {
+		if accesses[i] != e {
+			t.Fatalf("access %d: expected %+v, got %+v", i, e, accesses[i])
+		}
+	}
+	if accesses[4].Kind != addrtrace.MapBucket {
+		t.Fatalf("access 4: expected map bucket, got %+v", accesses[4])
+	}
+}
@@ -0,0 +1,106 @@
+// Package structlayout shows how struct fields are laid out in memory.
+package structlayout
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Field is the layout of a single struct field.
+type Field struct {
+	Name      string
+	Type      reflect.Type
+	Offset    int64
+	Size      int64
+	Align     int64
+	Padding   int64 // padding bytes after the field
+	FirstLine int64 // cache line of the first byte, for a struct starting at a line boundary
+	LastLine  int64 // cache line of the last byte
+}
+
+// Layout is the layout of a struct.
+type Layout struct {
+	Type     reflect.Type
+	Size     int64
+	Align    int64
+	LineSize int64
+	Padding  int64 // total padding bytes
+	Fields   []Field
+}
+
+// Inspect returns the layout of the struct type typ with lines of lineSize bytes.
+func Inspect(typ reflect.Type, lineSize int64) (Layout, error) {
+	if typ.Kind() != reflect.Struct {
+		return Layout{}, fmt.Errorf("%s: not a struct", typ)
+	}
+	if lineSize <= 0 {
+		return Layout{}, fmt.Errorf("bad line size: %d", lineSize)
+	}
+
+	l := Layout{
+		Type:     typ,
+		Size:     int64(typ.Size()),
+		Align:    int64(typ.Align()),
+		LineSize: lineSize,
+	}
+	for i := 0; i < typ.NumField(); i++ {
+		sf := typ.Field(i)
+		f := Field{
+			Name:   sf.Name,
+			Type:   sf.Type,
+			Offset: int64(sf.Offset),
+			Size:   int64(sf.Type.Size()),
+			Align:  int64(sf.Type.FieldAlign()),
+		}
+		f.FirstLine = f.Offset / lineSize
+		f.LastLine = f.FirstLine
+		if f.Size > 0 {
+			f.LastLine = (f.Offset + f.Size - 1) / lineSize
+		}
+		l.Fields = append(l.Fields, f)
+	}
+
+	for i := range l.Fields {
+		next := l.Size
+		if i < len(l.Fields)-1 {
+			next = l.Fields[i+1].Offset
+		}
+		f := &l.Fields[i]
+		f.Padding = next - (f.Offset + f.Size)
+		l.Padding += f.Padding
+	}
+
+	return l, nil
+}
+
+// Suggest returns a field order that minimizes the struct size, and that size.
+// Fields are sorted by alignment, largest first, keeping the original order
+// between fields of the same alignment.
+func (l Layout) Suggest() ([]string, int64) {
+	fields := make([]Field, len(l.Fields))
+	copy(fields, l.Fields)
+	sort.SliceStable(fields, func(i, j int) bool {
+		return fields[i].Align > fields[j].Align
+	})
+
+	names := make([]string, len(fields))
+	var offset int64
+	for i, f := range fields {
+		names[i] = f.Name
+		offset = alignUp(offset, f.Align) + f.Size
+	}
+
+	if len(fields) > 0 && fields[len(fields)-1].Size == 0 && offset > 0 {
+		offset++ // the compiler pads a trailing zero size field
+	}
+
+	return names, alignUp(offset, l.Align)
+}
+
+func alignUp(n, align int64) int64 {
+	if align <= 1 {
+		return n
+	}
+	return (n + align - 1) / align * align
+}
@@ -0,0 +1,86 @@
+package structlayout
+
+import (
+	"reflect"
+	"testing"
+)
+
+type user struct {
+	Login   string
+	Active  bool
+	Icon    []byte
+	Country string
+}
+
+type padded struct {
+	A bool
+	B int64
+	C bool
+	D int32
+	E bool
+}
+
+func TestInspect(t *testing.T) {
+	l, err := Inspect(reflect.TypeOf(user{}), 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if l.Size != 64 || l.Align != 8 || l.Padding != 7 {
+		t.Fatalf("bad layout: %+v", l)
+	}
+
+	expected := []struct {
+		name                string
+		offset, size, pad   int64
+		firstLine, lastLine int64
+	}{
+		{"Login", 0, 16, 0, 0, 0},
+		{"Active", 16, 1, 7, 0, 0},
+		{"Icon", 24, 24, 0, 0, 0},
+		{"Country", 48, 16, 0, 0, 0},
+	}
+	for i, e := range expected {
+		f := l.Fields[i]
+		if f.Name != e.name || f.Offset != e.offset || f.Size != e.size || f.Padding != e.pad || f.FirstLine != e.firstLine || f.LastLine != e.lastLine {
+			t.Errorf("field %d: expected %+v, got %+v", i, e, f)
+		}
+	}
+
+	l, err = Inspect(reflect.TypeOf(user{}), 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f := l.Fields[2]; f.FirstLine != 0 || f.LastLine != 1 {
+		t.Fatalf("Icon lines: got %d-%d", f.FirstLine, f.LastLine)
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	l, err := Inspect(reflect.TypeOf(padded{}), 64)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if l.Size != 32 || l.Padding != 17 {
+		t.Fatalf("bad layout: %+v", l)
+	}
+
+	order, size := l.Suggest()
+	expected := []string{"B", "D", "A", "C", "E"}
+	if !reflect.DeepEqual(order, expected) {
+		t.Fatalf("expected %v, got %v", expected, order)
+	}
+	if size != 16 {
+		t.Fatalf("expected size 16, got %d", size)
+	}
+}
+
+func TestInspectErrors(t *testing.T) {
+	if _, err := Inspect(reflect.TypeOf(1), 64); err == nil {
+		t.Fatal("int: no error")
+	}
+	if _, err := Inspect(reflect.TypeOf(user{}), 0); err == nil {
+		t.Fatal("zero line size: no error")
+	}
+}
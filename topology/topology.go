@@ -0,0 +1,411 @@
+// Package topology discovers the CPU cache hierarchy.
+package topology
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"os/exec"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrNoCaches is returned when no cache information is found.
+var ErrNoCaches = errors.New("no cache information found")
+
+// Cache types.
+const (
+	Data        = "Data"
+	Instruction = "Instruction"
+	Unified     = "Unified"
+)
+
+// Cache is a level of the cache hierarchy, e.g. L2.
+type Cache struct {
+	Name       string // L1d, L1i, L2 ... as printed by lscpu
+	Level      int
+	Type       string // Data, Instruction or Unified
+	Size       int64  // bytes, of a single instance
+	Ways       int
+	Sets       int
+	LineSize   int     // coherency line size in bytes
+	Instances  int     // number of caches of this kind in the system
+	SharedCPUs [][]int // CPUs sharing each instance, nil if unknown
+}
+
+// HoldsData reports if c caches data (is a Data or Unified cache).
+func (c Cache) HoldsData() bool {
+	return c.Type == Data || c.Type == Unified
+}
+
+// DataCaches returns the data and unified caches in caches, sorted by level.
+func DataCaches(caches []Cache) []Cache {
+	var data []Cache
+	for _, c := range caches {
+		if c.HoldsData() {
+			data = append(data, c)
+		}
+	}
+	sortCaches(data)
+	return data
+}
+
+// ForCPU returns the caches in caches used by cpu, which on hybrid CPUs
+// are a single cache per level and type. Caches with unknown SharedCPUs
+// are kept.
+func ForCPU(caches []Cache, cpu int) []Cache {
+	var used []Cache
+	for _, c := range caches {
+		if c.SharedCPUs == nil {
+			used = append(used, c)
+			continue
+		}
+		for _, cpus := range c.SharedCPUs {
+			if containsCPU(cpus, cpu) {
+				used = append(used, c)
+				break
+			}
+		}
+	}
+	return used
+}
+
+func containsCPU(cpus []int, cpu int) bool {
+	for _, c := range cpus {
+		if c == cpu {
+			return true
+		}
+	}
+	return false
+}
+
+const sysfsRoot = "/sys/devices/system/cpu"
+
+// Discover returns the caches of the current machine.
+// It reads sysfs and falls back to running "lscpu -C".
+func Discover() ([]Cache, error) {
+	caches, err := ReadSysfs(os.DirFS(sysfsRoot))
+	if err == nil {
+		return caches, nil
+	}
+
+	out, lerr := exec.Command("lscpu", "-C").Output()
+	if lerr != nil {
+		return nil, fmt.Errorf("sysfs: %s, lscpu: %w", err, lerr)
+	}
+
+	return ParseLscpu(bytes.NewReader(out))
+}
+
+// ReadSysfs reads caches from fsys, which should be rooted at /sys/devices/system/cpu.
+func ReadSysfs(fsys fs.FS) ([]Cache, error) {
+	dirs, err := fs.Glob(fsys, "cpu[0-9]*/cache/index[0-9]*")
+	if err != nil {
+		return nil, err
+	}
+
+	// Hybrid CPUs (e.g. P and E cores) have caches of the same level and
+	// type with different geometries, they are reported separately.
+	type key struct {
+		level int
+		typ   string
+		size  int64
+		ways  int
+		sets  int
+	}
+	byKey := make(map[key]*Cache)
+	shared := make(map[key]map[string]bool) // shared_cpu_list values seen
+	for _, dir := range dirs {
+		c, cpus, list, err := readIndex(fsys, dir)
+		if err != nil {
+			return nil, err
+		}
+
+		k := key{c.Level, c.Type, c.Size, c.Ways, c.Sets}
+		if byKey[k] == nil {
+			byKey[k] = &c
+			shared[k] = make(map[string]bool)
+		}
+		if !shared[k][list] {
+			shared[k][list] = true
+			byKey[k].SharedCPUs = append(byKey[k].SharedCPUs, cpus)
+		}
+	}
+
+	if len(byKey) == 0 {
+		return nil, ErrNoCaches
+	}
+
+	caches := make([]Cache, 0, len(byKey))
+	for _, c := range byKey {
+		c.Instances = len(c.SharedCPUs)
+		sort.Slice(c.SharedCPUs, func(i, j int) bool {
+			return firstCPU(c.SharedCPUs[i]) < firstCPU(c.SharedCPUs[j])
+		})
+		caches = append(caches, *c)
+	}
+	sortCaches(caches)
+
+	return caches, nil
+}
+
+func firstCPU(cpus []int) int {
+	if len(cpus) == 0 {
+		return -1
+	}
+	return cpus[0]
+}
+
+// readIndex reads a cache from a sysfs index directory.
+// It returns the cache, the CPUs sharing it and its raw shared_cpu_list.
+func readIndex(fsys fs.FS, dir string) (Cache, []int, string, error) {
+	read := func(name string) (string, error) {
+		data, err := fs.ReadFile(fsys, path.Join(dir, name))
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(data)), nil
+	}
+
+	var c Cache
+	var err error
+	ints := []struct {
+		name string
+		dest *int
+	}{
+		{"level", &c.Level},
+		{"ways_of_associativity", &c.Ways},
+		{"number_of_sets", &c.Sets},
+		{"coherency_line_size", &c.LineSize},
+	}
+	for _, i := range ints {
+		s, err := read(i.name)
+		if err != nil {
+			return Cache{}, nil, "", err
+		}
+		if *i.dest, err = strconv.Atoi(s); err != nil {
+			return Cache{}, nil, "", fmt.Errorf("%s/%s: %w", dir, i.name, err)
+		}
+	}
+
+	if c.Type, err = read("type"); err != nil {
+		return Cache{}, nil, "", err
+	}
+
+	size, err := read("size")
+	if err != nil {
+		return Cache{}, nil, "", err
+	}
+	if c.Size, err = ParseSize(size); err != nil {
+		return Cache{}, nil, "", fmt.Errorf("%s/size: %w", dir, err)
+	}
+
+	list, err := read("shared_cpu_list")
+	if err != nil {
+		return Cache{}, nil, "", err
+	}
+	cpus, err := ParseCPUList(list)
+	if err != nil {
+		return Cache{}, nil, "", fmt.Errorf("%s/shared_cpu_list: %w", dir, err)
+	}
+
+	c.Name = cacheName(c.Level, c.Type)
+	return c, cpus, list, nil
+}
+
+// cacheName returns the lscpu name of a cache, e.g. L1d.
+func cacheName(level int, typ string) string {
+	name := fmt.Sprintf("L%d", level)
+	switch typ {
+	case Data:
+		name += "d"
+	case Instruction:
+		name += "i"
+	}
+	return name
+}
+
+func sortCaches(caches []Cache) {
+	sort.Slice(caches, func(i, j int) bool {
+		if caches[i].Level != caches[j].Level {
+			return caches[i].Level < caches[j].Level
+		}
+		if caches[i].Name != caches[j].Name {
+			return caches[i].Name < caches[j].Name
+		}
+		return caches[i].Size < caches[j].Size
+	})
+}
+
+// ParseLscpu parses the output of "lscpu -C" (see cache.txt).
+// Lines before the header are ignored. SharedCPUs is nil since lscpu doesn't
+// print it, Instances is computed from ALL-SIZE/ONE-SIZE.
+func ParseLscpu(r io.Reader) ([]Cache, error) {
+	s := bufio.NewScanner(r)
+	var columns map[string]int
+	var caches []Cache
+	for s.Scan() {
+		fields := strings.Fields(s.Text())
+		if columns == nil {
+			if len(fields) > 0 && fields[0] == "NAME" {
+				columns = make(map[string]int)
+				for i, name := range fields {
+					columns[name] = i
+				}
+			}
+			continue
+		}
+
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != len(columns) {
+			return nil, fmt.Errorf("%q: expected %d fields, got %d", s.Text(), len(columns), len(fields))
+		}
+
+		c, err := lscpuCache(columns, fields)
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w", s.Text(), err)
+		}
+		caches = append(caches, c)
+	}
+
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(caches) == 0 {
+		return nil, ErrNoCaches
+	}
+
+	return caches, nil
+}
+
+func lscpuCache(columns map[string]int, fields []string) (Cache, error) {
+	field := func(name string) (string, error) {
+		i, ok := columns[name]
+		if !ok {
+			return "", fmt.Errorf("missing %s column", name)
+		}
+		return fields[i], nil
+	}
+
+	var c Cache
+	var err error
+	if c.Name, err = field("NAME"); err != nil {
+		return Cache{}, err
+	}
+	if c.Type, err = field("TYPE"); err != nil {
+		return Cache{}, err
+	}
+
+	ints := []struct {
+		name string
+		dest *int
+	}{
+		{"LEVEL", &c.Level},
+		{"WAYS", &c.Ways},
+		{"SETS", &c.Sets},
+		{"COHERENCY-SIZE", &c.LineSize},
+	}
+	for _, i := range ints {
+		s, err := field(i.name)
+		if err != nil {
+			return Cache{}, err
+		}
+		if *i.dest, err = strconv.Atoi(s); err != nil {
+			return Cache{}, fmt.Errorf("%s: %w", i.name, err)
+		}
+	}
+
+	one, err := field("ONE-SIZE")
+	if err != nil {
+		return Cache{}, err
+	}
+	if c.Size, err = ParseSize(one); err != nil {
+		return Cache{}, fmt.Errorf("ONE-SIZE: %w", err)
+	}
+
+	all, err := field("ALL-SIZE")
+	if err != nil {
+		return Cache{}, err
+	}
+	allSize, err := ParseSize(all)
+	if err != nil {
+		return Cache{}, fmt.Errorf("ALL-SIZE: %w", err)
+	}
+
+	c.Instances = 1
+	if c.Size > 0 {
+		c.Instances = int(math.Round(float64(allSize) / float64(c.Size)))
+	}
+
+	return c, nil
+}
+
+var sizeUnits = map[string]int64{
+	"":  1,
+	"B": 1,
+	"K": 1 << 10,
+	"M": 1 << 20,
+	"G": 1 << 30,
+}
+
+// ParseSize parses cache sizes such as "48K", "1.3M" or "48 KiB" to bytes.
+func ParseSize(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	s = strings.TrimSuffix(s, "iB")
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= '0' && r <= '9' || r == '.')
+	})
+	num, unit := s, ""
+	if i != -1 {
+		num, unit = s[:i], strings.TrimSpace(s[i:])
+	}
+
+	mul, ok := sizeUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("%q: unknown unit", s)
+	}
+
+	v, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%q: bad size", s)
+	}
+
+	return int64(math.Round(v * float64(mul))), nil
+}
+
+// ParseCPUList parses a CPU list such as "0-3,8,10-11".
+func ParseCPUList(s string) ([]int, error) {
+	var cpus []int
+	if s == "" {
+		return cpus, nil
+	}
+
+	for _, part := range strings.Split(s, ",") {
+		lo, hi, isRange := strings.Cut(part, "-")
+		start, err := strconv.Atoi(lo)
+		if err != nil {
+			return nil, fmt.Errorf("%q: bad CPU list", s)
+		}
+		end := start
+		if isRange {
+			if end, err = strconv.Atoi(hi); err != nil || end < start {
+				return nil, fmt.Errorf("%q: bad CPU list", s)
+			}
+		}
+		for cpu := start; cpu <= end; cpu++ {
+			cpus = append(cpus, cpu)
+		}
+	}
+
+	return cpus, nil
+}
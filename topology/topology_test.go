@@ -0,0 +1,169 @@
+package topology
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestReadSysfs(t *testing.T) {
+	caches, err := ReadSysfs(os.DirFS("testdata/sysfs"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Cache{
+		{"L1d", 1, Data, 48 << 10, 12, 64, 64, 2, [][]int{{0}, {1}}},
+		{"L1i", 1, Instruction, 32 << 10, 8, 64, 64, 2, [][]int{{0}, {1}}},
+		{"L2", 2, Unified, 1280 << 10, 10, 2048, 64, 2, [][]int{{0}, {1}}},
+		{"L3", 3, Unified, 12 << 20, 12, 16384, 64, 1, [][]int{{0, 1}}},
+	}
+	if !reflect.DeepEqual(caches, expected) {
+		t.Fatalf("expected\n%+v\ngot\n%+v", expected, caches)
+	}
+}
+
+func TestReadSysfsHybrid(t *testing.T) {
+	// Two hyper-threaded P-cores (0-1, 2-3) and two E-cores (4, 5) sharing an L2.
+	caches, err := ReadSysfs(os.DirFS("testdata/hybrid"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Cache{
+		{"L1d", 1, Data, 32 << 10, 8, 64, 64, 2, [][]int{{4}, {5}}},
+		{"L1d", 1, Data, 48 << 10, 12, 64, 64, 2, [][]int{{0, 1}, {2, 3}}},
+		{"L1i", 1, Instruction, 32 << 10, 8, 64, 64, 2, [][]int{{0, 1}, {2, 3}}},
+		{"L1i", 1, Instruction, 64 << 10, 8, 128, 64, 2, [][]int{{4}, {5}}},
+		{"L2", 2, Unified, 1280 << 10, 10, 2048, 64, 2, [][]int{{0, 1}, {2, 3}}},
+		{"L2", 2, Unified, 2 << 20, 16, 2048, 64, 1, [][]int{{4, 5}}},
+		{"L3", 3, Unified, 12 << 20, 12, 16384, 64, 1, [][]int{{0, 1, 2, 3, 4, 5}}},
+	}
+	if !reflect.DeepEqual(caches, expected) {
+		t.Fatalf("expected\n%+v\ngot\n%+v", expected, caches)
+	}
+
+	for _, cpu := range []int{0, 5} {
+		var sizes []int64
+		for _, c := range DataCaches(ForCPU(caches, cpu)) {
+			sizes = append(sizes, c.Size)
+		}
+		expected := []int64{48 << 10, 1280 << 10, 12 << 20} // P-core
+		if cpu == 5 {
+			expected = []int64{32 << 10, 2 << 20, 12 << 20} // E-core
+		}
+		if !reflect.DeepEqual(sizes, expected) {
+			t.Fatalf("cpu %d: expected %v, got %v", cpu, expected, sizes)
+		}
+	}
+}
+
+func TestReadSysfsErrors(t *testing.T) {
+	_, err := ReadSysfs(fstest.MapFS{})
+	if !errors.Is(err, ErrNoCaches) {
+		t.Fatalf("empty: expected ErrNoCaches, got %v", err)
+	}
+
+	if _, err := ReadSysfs(os.DirFS("testdata/broken")); err == nil {
+		t.Fatal("broken: no error")
+	}
+}
+
+func TestParseLscpu(t *testing.T) {
+	file, err := os.Open("../cache.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	caches, err := ParseLscpu(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Cache{
+		{"L1d", 1, Data, 48 << 10, 12, 64, 64, 7, nil},
+		{"L1i", 1, Instruction, 32 << 10, 8, 64, 64, 18, nil},
+		{"L2", 2, Unified, 1363149, 10, 2048, 64, 5, nil},
+		{"L3", 3, Unified, 12 << 20, 12, 16384, 64, 1, nil},
+	}
+	if !reflect.DeepEqual(caches, expected) {
+		t.Fatalf("expected\n%+v\ngot\n%+v", expected, caches)
+	}
+
+	data := DataCaches(caches)
+	if len(data) != 3 || data[0].Name != "L1d" || data[2].Name != "L3" {
+		t.Fatalf("bad data caches: %+v", data)
+	}
+}
+
+func TestParseLscpuErrors(t *testing.T) {
+	cases := []string{
+		"",
+		"NAME ONE-SIZE\nL1d\n",
+		"NAME ONE-SIZE ALL-SIZE WAYS TYPE LEVEL SETS PHY-LINE COHERENCY-SIZE\nL1d 48X 352K 12 Data 1 64 1 64\n",
+	}
+	for _, text := range cases {
+		if _, err := ParseLscpu(strings.NewReader(text)); err == nil {
+			t.Errorf("%q: no error", text)
+		}
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	cases := map[string]int64{
+		"64":     64,
+		"48K":    48 << 10,
+		"48 KiB": 48 << 10,
+		"1.5M":   3 << 19,
+		"2G":     2 << 30,
+	}
+	for s, expected := range cases {
+		size, err := ParseSize(s)
+		if err != nil {
+			t.Errorf("%q: %v", s, err)
+			continue
+		}
+		if size != expected {
+			t.Errorf("%q: expected %d, got %d", s, expected, size)
+		}
+	}
+
+	if _, err := ParseSize("12Q"); err == nil {
+		t.Error("12Q: no error")
+	}
+}
+
+func TestParseCPUList(t *testing.T) {
+	cpus, err := ParseCPUList("0-2,5,8-9")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []int{0, 1, 2, 5, 8, 9}
+	if !reflect.DeepEqual(cpus, expected) {
+		t.Fatalf("expected %v, got %v", expected, cpus)
+	}
+
+	for _, s := range []string{"a", "3-1", "1-b"} {
+		if _, err := ParseCPUList(s); err == nil {
+			t.Errorf("%q: no error", s)
+		}
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	caches, err := Discover()
+	if err != nil {
+		t.Skipf("can't discover caches: %s", err)
+	}
+
+	for _, c := range caches {
+		if c.Size <= 0 || c.LineSize <= 0 {
+			t.Fatalf("bad cache: %+v", c)
+		}
+	}
+}
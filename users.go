@@ -1 +0,0 @@
-array/users.go
\ No newline at end of file
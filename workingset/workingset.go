@@ -0,0 +1,138 @@
+// Package workingset computes how much memory a CountryCount style scan
+// touches and which cache levels it fits in.
+package workingset
+
+import (
+	"fmt"
+	"reflect"
+
+	"users/topology"
+)
+
+// Layout describes a slice of structs scanned by reading some of their fields.
+type Layout struct {
+	Name   string
+	Type   reflect.Type // struct type of the slice elements
+	Fields []string     // fields read by the scan, e.g. Active and Country
+	// Indirect is the number of heap bytes each element references but the
+	// scan doesn't read, e.g. 128*128 for the slice User Icon.
+	Indirect int64
+}
+
+// Fit is how the hot working set compares to a cache.
+type Fit struct {
+	Cache topology.Cache
+	Fits  bool
+	Ratio float64 // hot bytes / cache size
+}
+
+// Analysis is the result of Analyze.
+type Analysis struct {
+	Layout       Layout
+	Count        int
+	LineSize     int
+	ElemSize     int64   // bytes between elements
+	LinesPerElem float64 // average cache lines touched per element
+	HotBytes     int64   // bytes of cache lines read by the scan
+	TotalBytes   int64   // slice and indirect bytes
+	Fits         []Fit   // per data cache, from L1 up
+}
+
+// Analyze analyzes a scan of count elements of l against caches.
+// The line size is taken from the first data cache (64 if unknown).
+func Analyze(l Layout, count int, caches []topology.Cache) (Analysis, error) {
+	if l.Type.Kind() != reflect.Struct {
+		return Analysis{}, fmt.Errorf("%s: not a struct", l.Type)
+	}
+
+	data := topology.DataCaches(caches)
+	lineSize := 64
+	if len(data) > 0 && data[0].LineSize > 0 {
+		lineSize = data[0].LineSize
+	}
+
+	ranges, err := fieldRanges(l.Type, l.Fields)
+	if err != nil {
+		return Analysis{}, err
+	}
+
+	a := Analysis{
+		Layout:   l,
+		Count:    count,
+		LineSize: lineSize,
+		ElemSize: int64(l.Type.Size()),
+	}
+	a.TotalBytes = int64(count) * (a.ElemSize + l.Indirect)
+
+	if a.ElemSize < int64(lineSize) {
+		// Elements share lines, the scan streams through the whole slice.
+		a.LinesPerElem = float64(a.ElemSize) / float64(lineSize)
+		a.HotBytes = roundUp(int64(count)*a.ElemSize, int64(lineSize))
+	} else {
+		a.LinesPerElem = linesPerElem(ranges, a.ElemSize, int64(lineSize))
+		a.HotBytes = int64(a.LinesPerElem * float64(count) * float64(lineSize))
+	}
+
+	for _, c := range data {
+		a.Fits = append(a.Fits, Fit{
+			Cache: c,
+			Fits:  a.HotBytes <= c.Size,
+			Ratio: float64(a.HotBytes) / float64(c.Size),
+		})
+	}
+
+	return a, nil
+}
+
+// byteRange is [start, end) inside a struct.
+type byteRange struct {
+	start, end int64
+}
+
+func fieldRanges(typ reflect.Type, names []string) ([]byteRange, error) {
+	ranges := make([]byteRange, 0, len(names))
+	for _, name := range names {
+		f, ok := typ.FieldByName(name)
+		if !ok {
+			return nil, fmt.Errorf("%s: no field %q", typ, name)
+		}
+		start := int64(f.Offset)
+		ranges = append(ranges, byteRange{start, start + int64(f.Type.Size())})
+	}
+
+	return ranges, nil
+}
+
+// linesPerElem returns the average number of distinct lines ranges touch.
+// Element i starts at i*elemSize, so its offset inside a line repeats every
+// lineSize/gcd(elemSize, lineSize) elements; the average is over that period.
+func linesPerElem(ranges []byteRange, elemSize, lineSize int64) float64 {
+	period := lineSize / gcd(elemSize, lineSize)
+	total := 0
+	for i := int64(0); i < period; i++ {
+		base := (i * elemSize) % lineSize
+		lines := make(map[int64]bool)
+		for _, r := range ranges {
+			if r.end == r.start {
+				continue
+			}
+			for line := (base + r.start) / lineSize; line <= (base+r.end-1)/lineSize; line++ {
+				lines[line] = true
+			}
+		}
+		total += len(lines)
+	}
+
+	return float64(total) / float64(period)
+}
+
+func gcd(a, b int64) int64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+func roundUp(n, m int64) int64 {
+	return (n + m - 1) / m * m
+}
@@ -0,0 +1,92 @@
+package workingset
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"users/topology"
+)
+
+var caches = []topology.Cache{
+	{Name: "L1d", Level: 1, Type: topology.Data, Size: 48 << 10, LineSize: 64},
+	{Name: "L1i", Level: 1, Type: topology.Instruction, Size: 32 << 10, LineSize: 64},
+	{Name: "L2", Level: 2, Type: topology.Unified, Size: 1280 << 10, LineSize: 64},
+	{Name: "L3", Level: 3, Type: topology.Unified, Size: 12 << 20, LineSize: 64},
+}
+
+type small struct {
+	Active  bool
+	Country string
+}
+
+type big struct {
+	Login   string
+	Active  bool
+	Icon    [128 * 128]byte
+	Country string
+}
+
+func TestAnalyzeSmall(t *testing.T) {
+	l := Layout{Type: reflect.TypeOf(small{}), Fields: []string{"Active", "Country"}, Indirect: 100}
+	a, err := Analyze(l, 4096, caches)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if a.ElemSize != 24 || a.HotBytes != 4096*24 || a.TotalBytes != 4096*124 {
+		t.Fatalf("bad analysis: %+v", a)
+	}
+
+	if len(a.Fits) != 3 || a.Fits[0].Fits || !a.Fits[1].Fits {
+		t.Fatalf("bad fits: %+v", a.Fits)
+	}
+}
+
+func TestAnalyzeBig(t *testing.T) {
+	l := Layout{Type: reflect.TypeOf(big{}), Fields: []string{"Active", "Country"}}
+	a, err := Analyze(l, 10_000, caches)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Active and Country are 16K apart, always on different lines. Country
+	// is 16 bytes and crosses a line on some elements.
+	if a.LinesPerElem < 2 || a.LinesPerElem > 3 {
+		t.Fatalf("lines per element: got %v", a.LinesPerElem)
+	}
+
+	expected := int64(math.Round(a.LinesPerElem * 10_000 * 64))
+	if math.Abs(float64(a.HotBytes-expected)) > 1 {
+		t.Fatalf("hot bytes: expected %d, got %d", expected, a.HotBytes)
+	}
+
+	if a.Fits[1].Fits || !a.Fits[2].Fits {
+		t.Fatalf("bad fits: %+v", a.Fits)
+	}
+}
+
+func TestLinesPerElem(t *testing.T) {
+	// 8 bytes at offset 60 of a 128 byte element always cross a line.
+	lines := linesPerElem([]byteRange{{60, 68}}, 128, 64)
+	if lines != 2 {
+		t.Fatalf("expected 2, got %v", lines)
+	}
+
+	// With a 96 byte element, half the elements start mid line.
+	lines = linesPerElem([]byteRange{{0, 40}}, 96, 64)
+	if lines != 1.5 {
+		t.Fatalf("expected 1.5, got %v", lines)
+	}
+}
+
+func TestAnalyzeErrors(t *testing.T) {
+	if _, err := Analyze(Layout{Type: reflect.TypeOf(0)}, 1, caches); err == nil {
+		t.Fatal("int: no error")
+	}
+
+	l := Layout{Type: reflect.TypeOf(small{}), Fields: []string{"Login"}}
+	if _, err := Analyze(l, 1, caches); err == nil {
+		t.Fatal("missing field: no error")
+	}
+}